@@ -0,0 +1,689 @@
+package core
+
+import (
+	"encoding/json"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// API docs of the namespaces of a project, generated by --doc from the
+// metadata of the vars and namespaces defined in its files: :doc,
+// :arglists, :added, :deprecated and :superseded-by. As when linting,
+// the files are parsed without being evaluated. Vars and namespaces with
+// :no-doc metadata are left out, and so are private vars unless
+// DocOptions.Private is set. Docstrings may refer to other documented
+// vars as [[name]], [[ns/name]] or `name`, which become links.
+
+type (
+	DocOptions struct {
+		Format  string // "html" or "markdown"
+		Private bool
+		SrcDir  string
+		// SourceURL is the URL of the source of a var, with {path} (relative
+		// to SrcDir) and {line} placeholders. If empty, relative links to
+		// the files are used.
+		SourceURL string
+	}
+	apiDocVar struct {
+		vr   *Var
+		meta Map
+		pos  Position
+	}
+	apiDocNamespace struct {
+		name string
+		meta Map
+		pos  Position
+		vars []*apiDocVar
+	}
+	apiDocCollection struct {
+		namespaces map[string]*apiDocNamespace
+		vars       map[*Var]*apiDocVar
+	}
+	apiDocGenerator struct {
+		opts       DocOptions
+		outDir     string
+		ext        string
+		namespaces []*apiDocNamespace
+		vars       map[string]*apiDocVar
+	}
+	apiDocVarView struct {
+		NS           string
+		Name         string
+		Kind         string
+		Private      bool
+		Usages       []string
+		Doc          string
+		Added        string
+		IsDeprecated bool
+		Deprecated   string
+		SupersededBy string
+		Source       string
+	}
+	apiDocNamespaceView struct {
+		NS           string
+		Name         string
+		File         string
+		Doc          string
+		Summary      string
+		IsDeprecated bool
+		Deprecated   string
+		SupersededBy string
+		Source       string
+		Vars         []apiDocVarView
+	}
+	apiDocIndexView struct {
+		Title      string
+		Namespaces []apiDocNamespaceView
+	}
+	apiDocSearchEntry struct {
+		Name    string `json:"name"`
+		Kind    string `json:"kind"`
+		URL     string `json:"url"`
+		Summary string `json:"summary"`
+	}
+)
+
+var apiDocs *apiDocCollection
+
+var docRefRegex = regexp.MustCompile("\\[\\[([^\\[\\]\\s]+)\\]\\]|`([^`\\s]+)`")
+
+// StartDocCollection makes the linter record the metadata of the vars
+// and namespaces defined in the linted files for GenerateDocs.
+func StartDocCollection() {
+	apiDocs = &apiDocCollection{
+		namespaces: make(map[string]*apiDocNamespace),
+		vars:       make(map[*Var]*apiDocVar),
+	}
+}
+
+func (c *apiDocCollection) recordVar(vr *Var, meta Map, pos Position) {
+	if meta == nil {
+		meta = EmptyArrayMap()
+	}
+	// defmulti and the like define the same var more than once.
+	if d, ok := c.vars[vr]; ok {
+		d.meta = d.meta.Merge(meta)
+		return
+	}
+	c.vars[vr] = &apiDocVar{vr: vr, meta: meta, pos: pos}
+}
+
+func (c *apiDocCollection) recordNamespace(seq Seq) {
+	if name, meta, ok := namespaceMeta(seq); ok {
+		c.namespaces[name.ToString(false)] = &apiDocNamespace{
+			name: name.ToString(false),
+			meta: meta,
+			pos:  GetPosition(name),
+		}
+	}
+}
+
+func isNoDoc(meta Map) bool {
+	ok, v := meta.Get(KEYWORDS.noDoc)
+	return ok && ToBool(v)
+}
+
+// documented returns the namespaces to document, with their vars, of
+// those defined in files.
+func (c *apiDocCollection) documented(files []string, private bool) []*apiDocNamespace {
+	inFiles := make(map[string]bool)
+	for _, f := range files {
+		inFiles[filepath.Clean(f)] = true
+	}
+	isInFiles := func(pos Position) bool {
+		return pos.filename != nil && inFiles[filepath.Clean(*pos.filename)]
+	}
+	namespaces := make(map[string]*apiDocNamespace)
+	for name, ns := range c.namespaces {
+		if isInFiles(ns.pos) {
+			namespaces[name] = ns
+		}
+	}
+	for vr, d := range c.vars {
+		if !isInFiles(d.pos) || (vr.isPrivate && !private) || isNoDoc(d.meta) {
+			continue
+		}
+		name := vr.ns.Name.ToString(false)
+		ns, ok := namespaces[name]
+		if !ok {
+			// Defined without an ns form.
+			ns = &apiDocNamespace{name: name, meta: EmptyArrayMap()}
+			namespaces[name] = ns
+		}
+		ns.vars = append(ns.vars, d)
+	}
+	var res []*apiDocNamespace
+	for _, ns := range namespaces {
+		if isNoDoc(ns.meta) {
+			continue
+		}
+		sort.Slice(ns.vars, func(i, j int) bool {
+			return ns.vars[i].vr.name.ToString(false) < ns.vars[j].vr.name.ToString(false)
+		})
+		res = append(res, ns)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].name < res[j].name
+	})
+	return res
+}
+
+func metaString(meta Map, key Keyword) string {
+	ok, v := meta.Get(key)
+	if !ok || v.Equals(NIL) {
+		return ""
+	}
+	v = unquoted(v)
+	if s, ok := v.(String); ok {
+		return s.S
+	}
+	return v.ToString(false)
+}
+
+// deprecatedVersion returns the version that the :deprecated value of
+// meta names, if any.
+func deprecatedVersion(meta Map) string {
+	if _, d := meta.Get(KEYWORDS.deprecated); d != nil {
+		if version, ok := d.(String); ok {
+			return version.S
+		}
+	}
+	return ""
+}
+
+// trimDocIndent removes the indentation of the lines of doc after the
+// first one, which is common to all of them.
+func trimDocIndent(doc string) string {
+	lines := strings.Split(strings.TrimSpace(doc), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= indent && indent > 0 {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// docSummary returns the first line of doc, without the brackets of the
+// references to vars.
+func docSummary(doc string) string {
+	line, _, _ := strings.Cut(doc, "\n")
+	return docRefRegex.ReplaceAllString(strings.TrimSpace(line), "$1$2")
+}
+
+func (g *apiDocGenerator) nsFile(ns string) string {
+	return ns + g.ext
+}
+
+func (g *apiDocGenerator) varURL(d *apiDocVar) string {
+	return g.nsFile(d.vr.ns.Name.ToString(false)) + "#" + d.vr.name.ToString(false)
+}
+
+// resolve returns the documented var that name refers to in the
+// docstrings of ns, or nil.
+func (g *apiDocGenerator) resolve(ns string, name string) *apiDocVar {
+	if d, ok := g.vars[name]; ok {
+		return d
+	}
+	return g.vars[ns+"/"+name]
+}
+
+func (g *apiDocGenerator) sourceURL(pos Position) string {
+	if pos.filename == nil {
+		return ""
+	}
+	filename := *pos.filename
+	line := strconv.Itoa(pos.startLine)
+	if g.opts.SourceURL != "" {
+		path, err := filepath.Rel(g.opts.SrcDir, filename)
+		if err != nil {
+			path = filename
+		}
+		url := strings.ReplaceAll(g.opts.SourceURL, "{path}", filepath.ToSlash(path))
+		return strings.ReplaceAll(url, "{line}", line)
+	}
+	absFilename, err1 := filepath.Abs(filename)
+	absOutDir, err2 := filepath.Abs(g.outDir)
+	if err1 == nil && err2 == nil {
+		if path, err := filepath.Rel(absOutDir, absFilename); err == nil {
+			filename = path
+		}
+	}
+	return filepath.ToSlash(filename) + "#L" + line
+}
+
+// multimethodDispatch returns the expression of the dispatch fn of the
+// multimethod that defmulti defined as d, or nil if d is not one.
+func multimethodDispatch(d *apiDocVar) Expr {
+	b, ok := d.vr.expr.(*BindingExpr)
+	if !ok {
+		return nil
+	}
+	call, ok := b.binding.value.(*CallExpr)
+	if !ok || len(call.args) < 2 {
+		return nil
+	}
+	if ref, ok := call.callable.(*VarRefExpr); !ok || ref.vr.Name() != "joker.core/multimethod__" {
+		return nil
+	}
+	return call.args[1]
+}
+
+func varKind(d *apiDocVar) string {
+	if d.vr.isMacro {
+		return "Macro"
+	}
+	if multimethodDispatch(d) != nil {
+		return "Multimethod"
+	}
+	if ok, _ := d.meta.Get(KEYWORDS.arglist); ok {
+		return "Function"
+	}
+	if _, ok := d.vr.expr.(*FnExpr); ok {
+		return "Function"
+	}
+	return "Variable"
+}
+
+func varUsages(d *apiDocVar) []string {
+	var res []string
+	name := d.vr.name.ToString(false)
+	if ok, arglists := d.meta.Get(KEYWORDS.arglist); ok {
+		if s, ok := unquoted(arglists).(Seqable); ok {
+			for arglists := s.Seq(); !arglists.IsEmpty(); arglists = arglists.Rest() {
+				args, ok := arglists.First().(Seqable)
+				if !ok {
+					continue
+				}
+				usage := "(" + name
+				for args := args.Seq(); !args.IsEmpty(); args = args.Rest() {
+					usage += " " + args.First().ToString(true)
+				}
+				res = append(res, usage+")")
+			}
+		}
+	}
+	if len(res) == 0 {
+		if dispatch := multimethodDispatch(d); dispatch != nil {
+			return multimethodUsages(name, dispatch)
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+// multimethodUsages returns the usages of the multimethod name, which
+// takes the args of its dispatch fn if that is a fn literal.
+func multimethodUsages(name string, dispatch Expr) []string {
+	fn, ok := dispatch.(*FnExpr)
+	if !ok {
+		return []string{"(" + name + " & args)"}
+	}
+	var res []string
+	usage := func(args []Symbol, variadic bool) string {
+		usage := "(" + name
+		for i, arg := range args {
+			if variadic && i == len(args)-1 {
+				usage += " &"
+			}
+			usage += " " + arg.ToString(false)
+		}
+		return usage + ")"
+	}
+	for _, arity := range fn.arities {
+		res = append(res, usage(arity.args, false))
+	}
+	if fn.variadic != nil {
+		res = append(res, usage(fn.variadic.args, true))
+	}
+	return res
+}
+
+func (g *apiDocGenerator) namespaceView(ns *apiDocNamespace) apiDocNamespaceView {
+	doc := trimDocIndent(metaString(ns.meta, KEYWORDS.doc))
+	res := apiDocNamespaceView{
+		NS:           ns.name,
+		Name:         ns.name,
+		File:         g.nsFile(ns.name),
+		Doc:          doc,
+		Summary:      docSummary(doc),
+		IsDeprecated: isDeprecated(ns.meta),
+		Deprecated:   deprecatedVersion(ns.meta),
+		SupersededBy: metaString(ns.meta, KEYWORDS.supersededBy),
+		Source:       g.sourceURL(ns.pos),
+	}
+	for _, d := range ns.vars {
+		res.Vars = append(res.Vars, apiDocVarView{
+			NS:           ns.name,
+			Name:         d.vr.name.ToString(false),
+			Kind:         varKind(d),
+			Private:      d.vr.isPrivate,
+			Usages:       varUsages(d),
+			Doc:          trimDocIndent(metaString(d.meta, KEYWORDS.doc)),
+			Added:        metaString(d.meta, KEYWORDS.added),
+			IsDeprecated: isDeprecated(d.meta),
+			Deprecated:   deprecatedVersion(d.meta),
+			SupersededBy: metaString(d.meta, KEYWORDS.supersededBy),
+			Source:       g.sourceURL(d.pos),
+		})
+	}
+	return res
+}
+
+// linkRefs renders doc, a docstring of ns, with the references to
+// documented vars rendered by link and the rest of it by text.
+func (g *apiDocGenerator) linkRefs(ns string, doc string, text func(string) string, link func(name string, url string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range docRefRegex.FindAllStringSubmatchIndex(doc, -1) {
+		b.WriteString(text(doc[last:m[0]]))
+		last = m[1]
+		var name string
+		if m[2] >= 0 {
+			name = doc[m[2]:m[3]]
+		} else {
+			name = doc[m[4]:m[5]]
+		}
+		if d := g.resolve(ns, name); d != nil {
+			b.WriteString(link(name, g.varURL(d)))
+		} else {
+			b.WriteString(text(doc[m[0]:m[1]]))
+		}
+	}
+	b.WriteString(text(doc[last:]))
+	return b.String()
+}
+
+func (g *apiDocGenerator) htmlFuncs() htmltemplate.FuncMap {
+	link := func(name string, url string) string {
+		return `<a href="` + htmltemplate.HTMLEscapeString(url) + `"><code>` + htmltemplate.HTMLEscapeString(name) + `</code></a>`
+	}
+	return htmltemplate.FuncMap{
+		"doc": func(ns string, doc string) htmltemplate.HTML {
+			code := func(s string) string {
+				// Unresolved `name` references are still code.
+				s = htmltemplate.HTMLEscapeString(s)
+				if strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") && len(s) > 1 {
+					return "<code>" + s[1:len(s)-1] + "</code>"
+				}
+				return s
+			}
+			return htmltemplate.HTML(g.linkRefs(ns, doc, code, link))
+		},
+		"ref": func(ns string, name string) htmltemplate.HTML {
+			if d := g.resolve(ns, name); d != nil {
+				return htmltemplate.HTML(link(name, g.varURL(d)))
+			}
+			return htmltemplate.HTML("<code>" + htmltemplate.HTMLEscapeString(name) + "</code>")
+		},
+	}
+}
+
+var markdownEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownText escapes the HTML in s, a part of a docstring, outside of
+// its code spans, which Markdown renders literally.
+func markdownText(s string) string {
+	parts := strings.Split(s, "`")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = markdownEscaper.Replace(parts[i])
+	}
+	return strings.Join(parts, "`")
+}
+
+func (g *apiDocGenerator) markdownFuncs() template.FuncMap {
+	link := func(name string, url string) string {
+		return "[`" + name + "`](" + markdownEscaper.Replace(url) + ")"
+	}
+	return template.FuncMap{
+		"doc": func(ns string, doc string) string {
+			return g.linkRefs(ns, doc, markdownText, link)
+		},
+		"text": markdownText,
+		"ref": func(ns string, name string) string {
+			if d := g.resolve(ns, name); d != nil {
+				return link(name, g.varURL(d))
+			}
+			return "`" + name + "`"
+		},
+	}
+}
+
+const apiDocHTMLTemplates = `{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<header>
+<a href="index.html">Index</a>
+<div class="search">
+<input id="search" type="search" placeholder="Search" autocomplete="off">
+<ul id="search-results"></ul>
+</div>
+</header>
+<main>
+{{end}}
+{{define "footer"}}</main>
+<script src="search-index.js"></script>
+<script src="search.js"></script>
+</body>
+</html>
+{{end}}
+{{define "deprecated"}}{{if .IsDeprecated}}<p class="deprecated">Deprecated{{with .Deprecated}} since {{.}}{{end}}{{with .SupersededBy}}, use {{ref $.NS .}} instead{{end}}.</p>
+{{end}}{{end}}
+{{define "index"}}{{template "header" .Title}}<h1>{{.Title}}</h1>
+<ul class="namespaces">
+{{range .Namespaces}}<li><a href="{{.File}}">{{.Name}}</a>{{if .IsDeprecated}} <span class="deprecated">deprecated</span>{{end}}{{with .Summary}}<p>{{.}}</p>{{end}}</li>
+{{end}}</ul>
+{{template "footer"}}{{end}}
+{{define "ns"}}{{template "header" .Name}}<h1>{{.Name}}</h1>
+{{template "deprecated" .}}{{with .Doc}}<div class="doc">{{doc $.NS .}}</div>
+{{end}}{{with .Source}}<p class="source"><a href="{{.}}">Source</a></p>
+{{end}}<ul class="vars">
+{{range .Vars}}<li><a href="#{{.Name}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{range .Vars}}<section class="var" id="{{.Name}}">
+<h2>{{.Name}}</h2>
+<p class="kind">{{.Kind}}{{if .Private}} (private){{end}}{{with .Added}}, added in {{.}}{{end}}</p>
+<pre class="usage">{{range $i, $u := .Usages}}{{if $i}}
+{{end}}{{$u}}{{end}}</pre>
+{{template "deprecated" .}}{{with .Doc}}<div class="doc">{{doc $.NS .}}</div>
+{{end}}{{with .Source}}<p class="source"><a href="{{.}}">Source</a></p>
+{{end}}</section>
+{{end}}{{template "footer"}}{{end}}
+`
+
+const apiDocMarkdownTemplates = `{{define "deprecated"}}{{if .IsDeprecated}}**Deprecated{{with .Deprecated}} since {{text .}}{{end}}{{with .SupersededBy}}, use {{ref $.NS .}} instead{{end}}.**
+
+{{end}}{{end}}
+{{define "index"}}# {{.Title}}
+
+{{range .Namespaces}}- [{{.Name}}]({{.File}}){{if .IsDeprecated}} (deprecated){{end}}{{with .Summary}}: {{text .}}{{end}}
+{{end}}{{end}}
+{{define "ns"}}# {{.Name}}
+
+{{template "deprecated" .}}{{with .Doc}}{{doc $.NS .}}
+
+{{end}}{{with .Source}}[Source]({{.}})
+
+{{end}}{{range .Vars}}- [` + "`{{.Name}}`" + `](#{{html .Name}})
+{{end}}{{range .Vars}}
+## <a id="{{html .Name}}"></a>` + "`{{.Name}}`" + `
+
+*{{.Kind}}{{if .Private}} (private){{end}}{{with .Added}}, added in {{text .}}{{end}}*
+
+` + "```clojure" + `
+{{range .Usages}}{{.}}
+{{end}}` + "```" + `
+
+{{template "deprecated" .}}{{with .Doc}}{{doc $.NS .}}
+
+{{end}}{{with .Source}}[Source]({{.}})
+{{end}}{{end}}{{end}}
+`
+
+const apiDocCSS = `body { font-family: sans-serif; margin: 0; color: #222; }
+header { display: flex; gap: 2em; align-items: center; padding: 0.5em 2em; background: #eee; }
+main { max-width: 60em; padding: 1em 2em; }
+code, pre { font-family: monospace; }
+.doc { white-space: pre-wrap; }
+.kind { color: #666; font-style: italic; }
+.usage { background: #f6f6f6; padding: 0.5em; }
+.deprecated { color: #a00; }
+.vars { columns: 3; }
+.var { border-top: 1px solid #ddd; }
+.search { position: relative; }
+#search-results { position: absolute; list-style: none; margin: 0; padding: 0; background: #fff; min-width: 30em; }
+#search-results li { padding: 0.2em 0.5em; }
+#search-results span { color: #666; margin-left: 1em; }
+`
+
+const apiDocSearchJS = `(() => {
+  const input = document.getElementById('search');
+  const results = document.getElementById('search-results');
+
+  input.addEventListener('input', () => {
+    const query = input.value.trim().toLowerCase();
+    results.innerHTML = '';
+    if (query === '') {
+      return;
+    }
+    searchIndex
+      .filter(entry => entry.name.toLowerCase().includes(query))
+      .slice(0, 20)
+      .forEach(entry => {
+        const li = document.createElement('li');
+        const a = document.createElement('a');
+        a.href = entry.url;
+        a.textContent = entry.name;
+        li.appendChild(a);
+        const span = document.createElement('span');
+        span.textContent = entry.kind + (entry.summary ? ': ' + entry.summary : '');
+        li.appendChild(span);
+        results.appendChild(li);
+      });
+  });
+
+  input.addEventListener('keydown', e => {
+    const a = results.querySelector('a');
+    if (e.key === 'Enter' && a) {
+      window.location = a.href;
+    }
+  });
+})();
+`
+
+func (g *apiDocGenerator) searchIndex(views []apiDocNamespaceView) ([]byte, error) {
+	entries := []apiDocSearchEntry{}
+	for _, ns := range views {
+		entries = append(entries, apiDocSearchEntry{Name: ns.Name, Kind: "Namespace", URL: ns.File, Summary: ns.Summary})
+		for _, v := range ns.Vars {
+			entries = append(entries, apiDocSearchEntry{
+				Name:    ns.Name + "/" + v.Name,
+				Kind:    v.Kind,
+				URL:     ns.File + "#" + v.Name,
+				Summary: docSummary(v.Doc),
+			})
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	return []byte("var searchIndex = " + string(data) + ";\n"), nil
+}
+
+// GenerateDocs writes the API docs of the namespaces defined in files,
+// as recorded while linting them after StartDocCollection, to outDir.
+func GenerateDocs(outDir string, files []string, opts DocOptions) error {
+	g := &apiDocGenerator{
+		opts:       opts,
+		outDir:     outDir,
+		ext:        ".html",
+		namespaces: apiDocs.documented(files, opts.Private),
+		vars:       make(map[string]*apiDocVar),
+	}
+	if opts.Format == "markdown" {
+		g.ext = ".md"
+	}
+	for _, ns := range g.namespaces {
+		for _, d := range ns.vars {
+			g.vars[d.vr.Name()] = d
+		}
+	}
+	var views []apiDocNamespaceView
+	for _, ns := range g.namespaces {
+		views = append(views, g.namespaceView(ns))
+	}
+	title := "API documentation"
+	if abs, err := filepath.Abs(opts.SrcDir); err == nil {
+		title = filepath.Base(abs) + " " + title
+	}
+	var execute func(w *strings.Builder, name string, data interface{}) error
+	if opts.Format == "markdown" {
+		t := template.Must(template.New("docs").Funcs(g.markdownFuncs()).Parse(apiDocMarkdownTemplates))
+		execute = func(w *strings.Builder, name string, data interface{}) error {
+			return t.ExecuteTemplate(w, name, data)
+		}
+	} else {
+		t := htmltemplate.Must(htmltemplate.New("docs").Funcs(g.htmlFuncs()).Parse(apiDocHTMLTemplates))
+		execute = func(w *strings.Builder, name string, data interface{}) error {
+			return t.ExecuteTemplate(w, name, data)
+		}
+	}
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		return err
+	}
+	write := func(filename string, template string, data interface{}) error {
+		var b strings.Builder
+		if err := execute(&b, template, data); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(outDir, filename), []byte(b.String()), 0666)
+	}
+	if err := write("index"+g.ext, "index", apiDocIndexView{Title: title, Namespaces: views}); err != nil {
+		return err
+	}
+	for _, view := range views {
+		if err := write(view.File, "ns", view); err != nil {
+			return err
+		}
+	}
+	if opts.Format == "markdown" {
+		return nil
+	}
+	index, err := g.searchIndex(views)
+	if err != nil {
+		return err
+	}
+	for filename, data := range map[string][]byte{
+		"search-index.js": index,
+		"search.js":       []byte(apiDocSearchJS),
+		"style.css":       []byte(apiDocCSS),
+	} {
+		if err := os.WriteFile(filepath.Join(outDir, filename), data, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
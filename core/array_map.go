@@ -1,7 +1,6 @@
 package core
 
 import (
-	"fmt"
 	"io"
 )
 
@@ -313,23 +312,5 @@ func (m *ArrayMap) Format(w io.Writer, indent int) int {
 			i++
 		}
 	}
-	ind := indent + 1
-	fmt.Fprint(w, "{")
-	if len(arr) > 0 {
-		for i := 0; i < len(arr)-1; i++ {
-			ind = formatObject(arr[i], ind, w)
-			ind = maybeNewLine(w, arr[i], arr[i+1], indent+1, ind)
-		}
-		ind = formatObject(arr[len(arr)-1], ind, w)
-	}
-	if len(arr) > 0 {
-		if isComment(arr[len(arr)-1]) {
-			fmt.Fprint(w, "\n")
-			writeIndent(w, indent+1)
-			ind = indent + 1
-		}
-	}
-
-	fmt.Fprint(w, "}")
-	return ind + 1
+	return formatMap(arr, w, indent)
 }
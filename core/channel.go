@@ -20,6 +20,10 @@ func MakeFutureResult(value Object, err Error) FutureResult {
 	return FutureResult{value: value, err: err}
 }
 
+func (f FutureResult) Value() Object {
+	return f.value
+}
+
 func (ch *Channel) ToString(escape bool) string {
 	return "#object[Channel]"
 }
@@ -64,3 +68,30 @@ func (ch *Channel) Close() {
 		ch.isClosed = true
 	}
 }
+
+// Send puts value on the channel and returns whether it was sent,
+// which is not the case if the channel is (or gets) closed.
+// Must be called with the GIL released.
+func (ch *Channel) Send(value Object) bool {
+	sent, _ := ch.send(MakeFutureResult(value, nil), nil, nil)
+	return sent
+}
+
+// send puts res on the channel unless interrupt is closed first.
+// Must be called with the GIL released.
+func (ch *Channel) send(res FutureResult, interrupt <-chan struct{}, timeout <-chan struct{}) (sent bool, interrupted bool) {
+	defer func() {
+		// The channel has been closed.
+		if r := recover(); r != nil {
+			sent = false
+		}
+	}()
+	select {
+	case ch.ch <- res:
+		return true, false
+	case <-interrupt:
+		return false, true
+	case <-timeout:
+		return false, true
+	}
+}
@@ -0,0 +1,409 @@
+package core
+
+// Compilation converts Expr trees into Go closures, which evaluate
+// them without the per-node bookkeeping of Eval and with locals and
+// vars resolved ahead of time. Compiled code uses the same LocalEnv
+// frames as the tree-walker, so compiled and interpreted functions
+// can call each other and close over each other's locals.
+//
+// The units of compilation are top-level forms and fn arity bodies.
+// An arity is compiled the first time it is called, when the frame
+// number of its locals is known, so a local is addressed by the
+// (static) number of frames up the chain and its index in the frame.
+// Exprs without a compiled form fall back to Eval.
+
+type (
+	compiledExpr func(env *LocalEnv) Object
+	compiler     struct {
+		// Frame number of the innermost LocalEnv frame (-1 if none).
+		frame int
+	}
+)
+
+// SetCompilation turns compilation of Joker code on or off.
+// Must be called with the GIL held.
+func SetCompilation(enabled bool) {
+	RT.compile = enabled
+}
+
+// budgetStep is the compiled counterpart of the budget check in Eval.
+func budgetStep(expr Expr) {
+	if RT.budget != nil {
+		parentExpr := RT.currentExpr
+		RT.currentExpr = expr
+		RT.budget.step()
+		RT.currentExpr = parentExpr
+	}
+}
+
+// EvalTopLevel evaluates a top-level form (such as one read from
+// a file or the REPL), compiling it first if compilation is on.
+func EvalTopLevel(expr Expr) Object {
+	if RT.compile {
+		c := &compiler{frame: -1}
+		return c.compile(expr)(nil)
+	}
+	return Eval(expr, nil)
+}
+
+// evalBody evaluates the body of arity in env, whose innermost frame
+// holds the arguments.
+func (arity *FnArityExpr) evalBody(env *LocalEnv) Object {
+	if !RT.compile {
+		return evalLoop(arity.body, env)
+	}
+	if arity.compiled == nil || arity.compiledFrame != env.frame {
+		c := &compiler{frame: env.frame}
+		arity.compiled = c.compileLoop(arity.body)
+		arity.compiledFrame = env.frame
+	}
+	return arity.compiled(env)
+}
+
+func (c *compiler) compileSeq(exprs []Expr) []compiledExpr {
+	res := make([]compiledExpr, len(exprs))
+	for i, expr := range exprs {
+		res[i] = c.compile(expr)
+	}
+	return res
+}
+
+func runSeq(exprs []compiledExpr, env *LocalEnv) []Object {
+	res := make([]Object, len(exprs))
+	for i, expr := range exprs {
+		res[i] = expr(env)
+	}
+	return res
+}
+
+func (c *compiler) compileBody(exprs []Expr) compiledExpr {
+	switch len(exprs) {
+	case 0:
+		return func(env *LocalEnv) Object {
+			return NIL
+		}
+	case 1:
+		return c.compile(exprs[0])
+	}
+	body := c.compileSeq(exprs)
+	return func(env *LocalEnv) Object {
+		var res Object
+		for _, expr := range body {
+			res = expr(env)
+		}
+		return res
+	}
+}
+
+// compileLoop compiles the body of a loop or fn arity, whose innermost
+// frame is replaced by the values of recur.
+func (c *compiler) compileLoop(exprs []Expr) compiledExpr {
+	body := c.compileBody(exprs)
+	return func(env *LocalEnv) Object {
+		for {
+			res := body(env)
+			bindings, ok := res.(RecurBindings)
+			if !ok {
+				return res
+			}
+			CheckInterrupt()
+			env = env.replaceFrame(bindings)
+		}
+	}
+}
+
+// inFrame calls f to compile exprs evaluated in a new innermost frame.
+func (c *compiler) inFrame(f func()) {
+	c.frame++
+	f()
+	c.frame--
+}
+
+func (c *compiler) compile(expr Expr) compiledExpr {
+	switch expr := expr.(type) {
+	case *LiteralExpr:
+		obj := expr.obj
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return obj
+		}
+	case *VarRefExpr:
+		vr := expr.vr
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return vr.Resolve(true)
+		}
+	case *BindingExpr:
+		return c.compileBinding(expr)
+	case *IfExpr:
+		cond := c.compile(expr.cond)
+		positive := c.compile(expr.positive)
+		negative := c.compile(expr.negative)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			if ToBool(cond(env)) {
+				return positive(env)
+			}
+			return negative(env)
+		}
+	case *DoExpr:
+		body := c.compileBody(expr.body)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return body(env)
+		}
+	case *CallExpr:
+		return c.compileCall(expr)
+	case *VectorExpr:
+		elements := c.compileSeq(expr.v)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			res := EmptyArrayVector()
+			for _, e := range elements {
+				res.Append(e(env))
+			}
+			return res
+		}
+	case *MapExpr:
+		return c.compileMap(expr)
+	case *SetExpr:
+		elements := c.compileSeq(expr.elements)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			parentExpr := RT.currentExpr
+			RT.currentExpr = expr
+			defer func() { RT.currentExpr = parentExpr }()
+			res := EmptySet()
+			for _, e := range elements {
+				el := e(env)
+				if !res.Add(el) {
+					panic(RT.NewError("Duplicate set element: " + el.ToString(false)))
+				}
+			}
+			return res
+		}
+	case *LetExpr:
+		return c.compileLet(expr, expr.values, expr.body, c.compileBody)
+	case *LoopExpr:
+		return c.compileLet(expr, expr.values, expr.body, c.compileLoop)
+	case *RecurExpr:
+		args := c.compileSeq(expr.args)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return RecurBindings(runSeq(args, env))
+		}
+	case *DefExpr:
+		var value compiledExpr
+		if expr.value != nil {
+			value = c.compile(expr.value)
+		}
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			parentExpr := RT.currentExpr
+			RT.currentExpr = expr
+			defer func() { RT.currentExpr = parentExpr }()
+			if value != nil {
+				expr.vr.SetValue(value(env))
+			}
+			return expr.define(env)
+		}
+	case *ThrowExpr:
+		e := c.compile(expr.e)
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			parentExpr := RT.currentExpr
+			RT.currentExpr = expr
+			defer func() { RT.currentExpr = parentExpr }()
+			return throw(e(env))
+		}
+	case *TryExpr:
+		return c.compileTry(expr)
+	}
+	return func(env *LocalEnv) Object {
+		return Eval(expr, env)
+	}
+}
+
+func (c *compiler) compileBinding(expr *BindingExpr) compiledExpr {
+	index := expr.binding.index
+	switch hops := c.frame - expr.binding.frame; hops {
+	case 0:
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return env.bindings[index]
+		}
+	case 1:
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			return env.parent.bindings[index]
+		}
+	default:
+		return func(env *LocalEnv) Object {
+			budgetStep(expr)
+			for i := 0; i < hops; i++ {
+				env = env.parent
+			}
+			return env.bindings[index]
+		}
+	}
+}
+
+func (c *compiler) compileCall(expr *CallExpr) compiledExpr {
+	callable := c.compile(expr.callable)
+	args := c.compileSeq(expr.args)
+	return func(env *LocalEnv) Object {
+		budgetStep(expr)
+		parentExpr := RT.currentExpr
+		RT.currentExpr = expr
+		defer func() { RT.currentExpr = parentExpr }()
+		CheckInterrupt()
+		switch f := callable(env).(type) {
+		case *Fn:
+			return f.callCompiled(runSeq(args, env))
+		case Callable:
+			return f.Call(runSeq(args, env))
+		default:
+			panic(RT.NewErrorWithPos(f.ToString(false)+" is not a Fn", expr.callable.Pos()))
+		}
+	}
+}
+
+// callCompiled calls fn from compiled code. If the arity called just
+// passes its arguments on to a Proc (as joker.core's inc, +, and
+// many others do), the Proc is called directly, without evaluating
+// the arity's body.
+func (fn *Fn) callCompiled(args []Object) Object {
+	for i := range fn.fnExpr.arities {
+		arity := &fn.fnExpr.arities[i]
+		if len(arity.args) != len(args) {
+			continue
+		}
+		if call := arity.wrappedCall(); call != nil {
+			if proc, ok := call.callable.(*VarRefExpr).vr.Value.(Proc); ok {
+				RT.pushFrame()
+				defer RT.popFrame()
+				budgetStep(call)
+				RT.currentExpr = call
+				return proc.Call(args)
+			}
+		}
+		break
+	}
+	return fn.Call(args)
+}
+
+// wrappedCall returns the body of arity if it consists of a single
+// call of a var with the arity's arguments, in order.
+func (arity *FnArityExpr) wrappedCall() *CallExpr {
+	if arity.wrapChecked {
+		return arity.wrapped
+	}
+	arity.wrapChecked = true
+	if len(arity.body) != 1 {
+		return nil
+	}
+	call, ok := arity.body[0].(*CallExpr)
+	if !ok || len(call.args) != len(arity.args) {
+		return nil
+	}
+	if _, ok := call.callable.(*VarRefExpr); !ok {
+		return nil
+	}
+	for i, arg := range call.args {
+		b, ok := arg.(*BindingExpr)
+		if !ok || b.binding.index != i || b.binding.name.name != arity.args[i].name {
+			return nil
+		}
+	}
+	arity.wrapped = call
+	return call
+}
+
+func (c *compiler) compileMap(expr *MapExpr) compiledExpr {
+	keys := c.compileSeq(expr.keys)
+	values := c.compileSeq(expr.values)
+	isHashMap := int64(len(expr.keys)) > HASHMAP_THRESHOLD/2
+	return func(env *LocalEnv) Object {
+		budgetStep(expr)
+		parentExpr := RT.currentExpr
+		RT.currentExpr = expr
+		defer func() { RT.currentExpr = parentExpr }()
+		if isHashMap {
+			res := EmptyHashMap
+			for i := range keys {
+				key := keys[i](env)
+				if res.containsKey(key) {
+					panic(RT.NewError("Duplicate key: " + key.ToString(false)))
+				}
+				res = res.Assoc(key, values[i](env)).(*HashMap)
+			}
+			return res
+		}
+		res := EmptyArrayMap()
+		for i := range keys {
+			key := keys[i](env)
+			if !res.Add(key, values[i](env)) {
+				panic(RT.NewError("Duplicate key: " + key.ToString(false)))
+			}
+		}
+		return res
+	}
+}
+
+// compileLet compiles let and loop, whose values are stored in slots
+// of a new frame, in which body is evaluated.
+func (c *compiler) compileLet(expr Expr, valueExprs []Expr, bodyExprs []Expr, compileBody func([]Expr) compiledExpr) compiledExpr {
+	var values []compiledExpr
+	var body compiledExpr
+	c.inFrame(func() {
+		values = c.compileSeq(valueExprs)
+		body = compileBody(bodyExprs)
+	})
+	return func(env *LocalEnv) Object {
+		budgetStep(expr)
+		env = env.addFrame(make([]Object, len(values)))
+		for i, value := range values {
+			env.bindings[i] = value(env)
+		}
+		return body(env)
+	}
+}
+
+func (c *compiler) compileTry(expr *TryExpr) compiledExpr {
+	body := c.compileBody(expr.body)
+	catches := make([]compiledExpr, len(expr.catches))
+	for i, catchExpr := range expr.catches {
+		c.inFrame(func() {
+			catches[i] = c.compileBody(catchExpr.body)
+		})
+	}
+	var finally compiledExpr
+	if expr.finallyExpr != nil {
+		finally = c.compileBody(expr.finallyExpr)
+	}
+	return func(env *LocalEnv) (obj Object) {
+		budgetStep(expr)
+		parentExpr := RT.currentExpr
+		RT.currentExpr = expr
+		defer func() { RT.currentExpr = parentExpr }()
+		defer func() {
+			defer func() {
+				if finally != nil {
+					finally(env)
+				}
+			}()
+			if r := recover(); r != nil {
+				if r, ok := r.(Error); ok {
+					for i, catchExpr := range expr.catches {
+						if IsInstance(catchExpr.excType, r) {
+							obj = catches[i](env.addFrame([]Object{r}))
+							return
+						}
+					}
+				}
+				panic(r)
+			}
+		}()
+		return body(env)
+	}
+}
@@ -6,12 +6,21 @@ package core
 var haveSetCoreNamespaces bool
 
 func ProcessCoreData() {
+	// Subsequent calls (e.g. when creating interpreters) do nothing.
+	if haveSetCoreNamespaces {
+		return
+	}
 	processData(g_customlibsData)
 	// Let MaybeLazy() handle initialization.
-	if !haveSetCoreNamespaces {
-		setCoreNamespaces()
-		haveSetCoreNamespaces = true
-	}
+	setCoreNamespaces()
+	haveSetCoreNamespaces = true
+}
+
+// loadCoreData makes sure the default environment has joker.core loaded
+// and referred to by the user namespace.
+func loadCoreData() {
+	ProcessCoreData()
+	GLOBAL_ENV.ReferCoreToUser()
 }
 
 func ProcessReplData() {
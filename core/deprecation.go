@@ -0,0 +1,125 @@
+package core
+
+// Warnings on the use of deprecated vars and namespaces, i.e. those
+// whose metadata has :deprecated (true or the version they were
+// deprecated in) and, optionally, :superseded-by naming the replacement.
+// The linted code is not evaluated, so the metadata of the vars and
+// namespaces it defines is taken from the forms as read. Namespaces and
+// vars listed in :allowed-deprecated of the .joker config are not
+// reported.
+
+var (
+	deprecatedVars       = make(map[*Var]Map)
+	deprecatedNamespaces = make(map[*string]Map)
+)
+
+func isDeprecated(meta Map) bool {
+	if meta == nil {
+		return false
+	}
+	ok, d := meta.Get(KEYWORDS.deprecated)
+	return ok && ToBool(d)
+}
+
+// recordDeprecatedVar records the metadata of vr, defined in the linted
+// code, if it's deprecated.
+func recordDeprecatedVar(vr *Var, meta Map) {
+	if isDeprecated(meta) {
+		deprecatedVars[vr] = meta
+	} else {
+		delete(deprecatedVars, vr)
+	}
+}
+
+// namespaceMeta returns the name of the namespace created by seq, an ns
+// form, and its metadata, including the docstring and attribute map.
+func namespaceMeta(seq Seq) (Symbol, Map, bool) {
+	name, ok := Second(seq).(Symbol)
+	if !ok {
+		return Symbol{}, nil, false
+	}
+	var meta Map = EmptyArrayMap()
+	if m := name.GetMeta(); m != nil {
+		meta = m
+	}
+	references := seq.Rest().Rest()
+	if doc, ok := references.First().(String); ok {
+		meta = meta.Assoc(KEYWORDS.doc, doc).(Map)
+		references = references.Rest()
+	}
+	if m, ok := references.First().(Map); ok {
+		meta = meta.Merge(m)
+	}
+	return name, meta, true
+}
+
+// recordDeprecatedNamespace records the metadata of the namespace
+// created by seq, an ns form, if it's deprecated.
+func recordDeprecatedNamespace(seq Seq) {
+	name, meta, ok := namespaceMeta(seq)
+	if !ok {
+		return
+	}
+	if isDeprecated(meta) {
+		deprecatedNamespaces[name.name] = meta
+	} else {
+		delete(deprecatedNamespaces, name.name)
+	}
+}
+
+func isAllowedDeprecated(vr *Var) bool {
+	if ok, _ := WARNINGS.allowedDeprecated.Get(vr.ns.Name); ok {
+		return true
+	}
+	sym := Symbol{
+		ns:   vr.ns.Name.name,
+		name: vr.name.name,
+	}
+	ok, _ := WARNINGS.allowedDeprecated.Get(sym)
+	return ok
+}
+
+func deprecationMessage(what string, meta Map) string {
+	msg := what + " is deprecated"
+	if _, d := meta.Get(KEYWORDS.deprecated); d != nil {
+		if version, ok := d.(String); ok {
+			msg += " since " + version.S
+		}
+	}
+	if ok, s := meta.Get(KEYWORDS.supersededBy); ok && !s.Equals(NIL) {
+		msg += ", use " + unquoted(s).ToString(false) + " instead"
+	}
+	return msg
+}
+
+// unquoted returns the object quoted by obj, a value of the metadata of
+// the linted code (which is not evaluated), or obj if it's not quoted.
+func unquoted(obj Object) Object {
+	if q, ok := obj.(Seq); ok && q.First().Equals(SYMBOLS.quote) {
+		return Second(q)
+	}
+	return obj
+}
+
+// warnIfDeprecated warns if vr, referenced by obj, or its namespace is
+// deprecated, unless it's referenced from its own namespace.
+func warnIfDeprecated(vr *Var, obj Object) {
+	if !LINTER_MODE || vr.ns == nil || vr.isFake || vr.ns == GLOBAL_ENV.CurrentNamespace() || !isWrittenByUser(obj) || isAllowedDeprecated(vr) {
+		return
+	}
+	meta, ok := deprecatedVars[vr]
+	if !ok {
+		meta = vr.GetMeta()
+	}
+	if isDeprecated(meta) {
+		printParseWarning(GetPosition(obj), deprecationMessage("var "+vr.Name(), meta))
+		return
+	}
+	meta, ok = deprecatedNamespaces[vr.ns.Name.name]
+	if !ok {
+		meta = vr.ns.GetMeta()
+	}
+	if isDeprecated(meta) {
+		printParseWarning(GetPosition(obj), deprecationMessage("namespace "+vr.ns.Name.ToString(false), meta))
+	}
+}
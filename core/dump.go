@@ -0,0 +1,375 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Dumping read forms and parsed expressions as data (maps, vectors,
+// and scalars), so tools can print them as EDN or JSON.
+
+// Kinds of expressions, as named after the *_EXPR constants in pack.go.
+var exprKinds = map[int]string{
+	LITERAL_EXPR:   "literal",
+	VECTOR_EXPR:    "vector",
+	MAP_EXPR:       "map",
+	SET_EXPR:       "set",
+	IF_EXPR:        "if",
+	DEF_EXPR:       "def",
+	CALL_EXPR:      "call",
+	RECUR_EXPR:     "recur",
+	META_EXPR:      "meta",
+	DO_EXPR:        "do",
+	FN_ARITY_EXPR:  "fn-arity",
+	FN_EXPR:        "fn",
+	LET_EXPR:       "let",
+	THROW_EXPR:     "throw",
+	CATCH_EXPR:     "catch",
+	TRY_EXPR:       "try",
+	VARREF_EXPR:    "var-ref",
+	BINDING_EXPR:   "binding",
+	LOOP_EXPR:      "loop",
+	SET_MACRO_EXPR: "set-macro",
+	DOT_EXPR:       "dot",
+	SETNOW_EXPR:    "set-now",
+}
+
+type dataMap struct {
+	m *ArrayMap
+}
+
+func newDataMap(kind string) dataMap {
+	d := dataMap{m: EmptyArrayMap()}
+	d.add("kind", MakeKeyword(kind))
+	return d
+}
+
+func (d dataMap) add(key string, value Object) {
+	d.m.Add(MakeKeyword(key), value)
+}
+
+func (d dataMap) addString(key, value string) {
+	d.add(key, MakeString(value))
+}
+
+func (d dataMap) addPosition(pos Position) {
+	if pos.filename != nil {
+		d.addString("file", *pos.filename)
+	}
+	if pos.startLine == 0 {
+		return
+	}
+	d.add("line", MakeInt(pos.startLine))
+	d.add("column", MakeInt(pos.startColumn))
+	d.add("end-line", MakeInt(pos.endLine))
+	d.add("end-column", MakeInt(pos.endColumn))
+}
+
+func dataVector(objs []Object) Object {
+	return NewVectorFrom(objs...)
+}
+
+func sortByPosition(objs []Object) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		pi, pj := GetPosition(objs[i]), GetPosition(objs[j])
+		if pi.startLine != pj.startLine {
+			return pi.startLine < pj.startLine
+		}
+		return pi.startColumn < pj.startColumn
+	})
+}
+
+// FormData returns obj, a form read by the reader, as data: a map
+// with the :kind of the form (:list, :symbol, :comment, etc.), its
+// :source text, its position (:file, :line, :column, :end-line,
+// :end-column), its :prefix (such as "'" or "#_", when read in format
+// mode), and, for collections, its :children.
+func FormData(obj Object) Object {
+	var d dataMap
+	switch obj := obj.(type) {
+	case Comment:
+		d = newDataMap("comment")
+		d.addString("source", obj.C)
+	case Seq:
+		d = newDataMap("list")
+		d.add("children", formsData(ToSlice(obj)))
+	case Vec:
+		d = newDataMap("vector")
+		d.add("children", formsData(ToSlice(obj.Seq())))
+	case Map:
+		d = newDataMap("map")
+		var children []Object
+		for iter := obj.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			children = append(children, p.Key)
+			// In format mode, comments in maps are followed by surrogate values.
+			if !(FORMAT_MODE && isComment(p.Key)) {
+				children = append(children, p.Value)
+			}
+		}
+		d.add("children", formsData(children))
+	case Set:
+		d = newDataMap("set")
+		children := ToSlice(obj.(Seqable).Seq())
+		sortByPosition(children)
+		d.add("children", formsData(children))
+	case Symbol:
+		d = newDataMap("symbol")
+		d.addString("source", obj.ToString(false))
+		if obj.ns != nil {
+			d.addString("ns", obj.Namespace())
+		}
+		d.addString("name", obj.Name())
+	case Keyword:
+		d = newDataMap("keyword")
+		d.addString("source", obj.ToString(false))
+		if obj.ns != nil {
+			d.addString("ns", obj.Namespace())
+		}
+		d.addString("name", obj.Name())
+	case String:
+		d = newDataMap("string")
+		d.addString("source", obj.ToString(true))
+		d.add("value", obj)
+	case Int, Double:
+		d = newDataMap("number")
+		d.addString("source", obj.ToString(true))
+		d.add("value", obj)
+	case Number:
+		d = newDataMap("number")
+		d.addString("source", obj.ToString(true))
+	case Boolean:
+		d = newDataMap("boolean")
+		d.addString("source", obj.ToString(true))
+		d.add("value", obj)
+	case Nil:
+		d = newDataMap("nil")
+		d.addString("source", "nil")
+	case Char:
+		d = newDataMap("char")
+		d.addString("source", obj.ToString(true))
+	case *Regex:
+		d = newDataMap("regex")
+		d.addString("source", obj.ToString(true))
+	default:
+		d = newDataMap("other")
+		d.addString("type", obj.GetType().ToString(false))
+		d.addString("source", obj.ToString(true))
+	}
+	if info := obj.GetInfo(); info != nil {
+		if info.prefix != "" {
+			d.addString("prefix", info.prefix)
+		}
+		d.addPosition(info.Position)
+	}
+	return d.m
+}
+
+func formsData(objs []Object) Object {
+	res := make([]Object, len(objs))
+	for i, obj := range objs {
+		res[i] = FormData(obj)
+	}
+	return dataVector(res)
+}
+
+func exprsData(exprs []Expr) Object {
+	res := make([]Object, len(exprs))
+	for i, expr := range exprs {
+		res[i] = ExprData(expr)
+	}
+	return dataVector(res)
+}
+
+func symbolsData(syms []Symbol) Object {
+	res := make([]Object, len(syms))
+	for i, sym := range syms {
+		res[i] = MakeString(sym.ToString(false))
+	}
+	return dataVector(res)
+}
+
+func fnArityData(arity *FnArityExpr) Object {
+	d := newDataMap(exprKinds[FN_ARITY_EXPR])
+	d.addPosition(arity.Position)
+	d.add("args", symbolsData(arity.args))
+	d.add("body", exprsData(arity.body))
+	return d.m
+}
+
+func catchData(catch *CatchExpr) Object {
+	d := newDataMap(exprKinds[CATCH_EXPR])
+	d.addPosition(catch.Position)
+	d.addString("type", catch.excType.ToString(false))
+	d.addString("local", catch.excSymbol.ToString(false))
+	d.add("body", exprsData(catch.body))
+	return d.m
+}
+
+// ExprData returns expr, an expression produced by the parser, as
+// data: a map with the :kind of the expression (named after the
+// *_EXPR constants in pack.go, e.g. :call or :var-ref), its position,
+// and its parts, such as the resolved :var of a var reference or the
+// :frame and :index of a local binding.
+func ExprData(expr Expr) Object {
+	var d dataMap
+	switch expr := expr.(type) {
+	case *LiteralExpr:
+		d = newDataMap(exprKinds[LITERAL_EXPR])
+		d.add("value", FormData(expr.obj))
+	case *VectorExpr:
+		d = newDataMap(exprKinds[VECTOR_EXPR])
+		d.add("elements", exprsData(expr.v))
+	case *MapExpr:
+		d = newDataMap(exprKinds[MAP_EXPR])
+		d.add("keys", exprsData(expr.keys))
+		d.add("values", exprsData(expr.values))
+	case *SetExpr:
+		d = newDataMap(exprKinds[SET_EXPR])
+		d.add("elements", exprsData(expr.elements))
+	case *IfExpr:
+		d = newDataMap(exprKinds[IF_EXPR])
+		d.add("cond", ExprData(expr.cond))
+		d.add("then", ExprData(expr.positive))
+		d.add("else", ExprData(expr.negative))
+	case *DefExpr:
+		d = newDataMap(exprKinds[DEF_EXPR])
+		d.addString("var", expr.vr.Name())
+		if meta := expr.name.GetMeta(); meta != nil {
+			if ok, doc := meta.Get(KEYWORDS.doc); ok {
+				d.add("doc", doc)
+			}
+			if ok, private := meta.Get(KEYWORDS.private); ok {
+				d.add("private", private)
+			}
+		}
+		if expr.value != nil {
+			d.add("value", ExprData(expr.value))
+		}
+		if expr.meta != nil {
+			d.add("meta", ExprData(expr.meta))
+		}
+	case *CallExpr:
+		d = newDataMap(exprKinds[CALL_EXPR])
+		d.addString("name", expr.Name())
+		d.add("callable", ExprData(expr.callable))
+		d.add("args", exprsData(expr.args))
+	case *MacroCallExpr:
+		d = newDataMap("macro-call")
+		d.addString("name", expr.name)
+	case *RecurExpr:
+		d = newDataMap(exprKinds[RECUR_EXPR])
+		d.add("args", exprsData(expr.args))
+	case *VarRefExpr:
+		d = newDataMap(exprKinds[VARREF_EXPR])
+		d.addString("var", expr.vr.Name())
+	case *BindingExpr:
+		d = newDataMap(exprKinds[BINDING_EXPR])
+		d.addString("name", expr.binding.name.ToString(false))
+		d.add("frame", MakeInt(expr.binding.frame))
+		d.add("index", MakeInt(expr.binding.index))
+	case *MetaExpr:
+		d = newDataMap(exprKinds[META_EXPR])
+		d.add("meta", ExprData(expr.meta))
+		d.add("expr", ExprData(expr.expr))
+	case *DoExpr:
+		d = newDataMap(exprKinds[DO_EXPR])
+		d.add("body", exprsData(expr.body))
+	case *FnExpr:
+		d = newDataMap(exprKinds[FN_EXPR])
+		if expr.self.name != nil {
+			d.addString("self", expr.self.ToString(false))
+		}
+		arities := make([]Object, len(expr.arities))
+		for i := range expr.arities {
+			arities[i] = fnArityData(&expr.arities[i])
+		}
+		d.add("arities", dataVector(arities))
+		if expr.variadic != nil {
+			d.add("variadic", fnArityData(expr.variadic))
+		}
+	case *LetExpr:
+		d = newDataMap(exprKinds[LET_EXPR])
+		d.add("names", symbolsData(expr.names))
+		d.add("values", exprsData(expr.values))
+		d.add("body", exprsData(expr.body))
+	case *LoopExpr:
+		d = newDataMap(exprKinds[LOOP_EXPR])
+		d.add("names", symbolsData(expr.names))
+		d.add("values", exprsData(expr.values))
+		d.add("body", exprsData(expr.body))
+	case *ThrowExpr:
+		d = newDataMap(exprKinds[THROW_EXPR])
+		d.add("exception", ExprData(expr.e))
+	case *TryExpr:
+		d = newDataMap(exprKinds[TRY_EXPR])
+		d.add("body", exprsData(expr.body))
+		catches := make([]Object, len(expr.catches))
+		for i, catch := range expr.catches {
+			catches[i] = catchData(catch)
+		}
+		d.add("catches", dataVector(catches))
+		if expr.finallyExpr != nil {
+			d.add("finally", exprsData(expr.finallyExpr))
+		}
+	case *SetMacroExpr:
+		d = newDataMap(exprKinds[SET_MACRO_EXPR])
+		d.addString("var", expr.vr.Name())
+	case *DotExpr:
+		d = newDataMap(exprKinds[DOT_EXPR])
+		d.add("instance", ExprData(expr.instance))
+		d.addString("member", expr.member.ToString(false))
+		d.add("args", exprsData(expr.args))
+	case *SetNowExpr:
+		d = newDataMap(exprKinds[SETNOW_EXPR])
+		d.addString("target", expr.target.ToString(false))
+		d.add("value", ExprData(expr.value))
+	default:
+		d = newDataMap("other")
+		d.addString("type", fmt.Sprintf("%T", expr))
+	}
+	d.addPosition(expr.Pos())
+	return d.m
+}
+
+// DumpReader reads forms from reader and, depending on phase (READ or
+// PARSE), calls print with the FormData of each form, read in format
+// mode, or the ExprData of its parsed expression.
+func DumpReader(reader *Reader, filename string, phase Phase, print func(Object)) error {
+	if phase == READ {
+		FORMAT_MODE = true
+		HASHMAP_THRESHOLD = 100000
+	}
+	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
+	if filename != "" {
+		currentFilename := parseContext.GlobalEnv.file.Value
+		defer func() {
+			parseContext.GlobalEnv.SetFilename(currentFilename)
+		}()
+		s, err := filepath.Abs(filename)
+		PanicOnErr(err)
+		parseContext.GlobalEnv.SetFilename(MakeString(s))
+	}
+	for {
+		obj, err := TryRead(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(Stderr, err)
+			return err
+		}
+		if phase == READ {
+			print(FormData(obj))
+			continue
+		}
+		expr, err := TryParse(obj, parseContext)
+		if err != nil {
+			fmt.Fprintln(Stderr, err)
+			return err
+		}
+		print(ExprData(expr))
+	}
+}
@@ -34,6 +34,7 @@ type (
 		version       *Var
 		libs          *Var
 		Features      Set
+		sandbox       *Sandbox
 	}
 )
 
@@ -55,9 +56,9 @@ func (env *Env) SetEnvArgs(newArgs []string) {
 		args.Append(MakeString(arg))
 	}
 	if args.Count() > 0 {
-		env.args.Value = args.Seq()
+		env.args.SetValue(args.Seq())
 	} else {
-		env.args.Value = NIL
+		env.args.SetValue(NIL)
 	}
 }
 
@@ -76,7 +77,7 @@ func (env *Env) SetClassPath(cp string) {
 	if cpVec.Count() == 0 {
 		cpVec.Append(MakeString(""))
 	}
-	env.classPath.Value = cpVec
+	env.classPath.SetValue(cpVec)
 }
 
 /*
@@ -106,9 +107,9 @@ func EnsureLoaded(name string) {
 }
 
 func (env *Env) SetStdIO(stdin, stdout, stderr Object) {
-	env.stdin.Value = stdin
-	env.stdout.Value = stdout
-	env.stderr.Value = stderr
+	env.stdin.SetValue(stdin)
+	env.stdout.SetValue(stdout)
+	env.stderr.SetValue(stderr)
 }
 
 func (env *Env) StdIO() (stdin, stdout, stderr Object) {
@@ -122,7 +123,7 @@ This runs after invariant initialization, which includes calling
 	initializations must be reflected in gen_code/gen_code.go.
 */
 func (env *Env) SetMainFilename(filename string) {
-	env.MainFile.Value = MakeString(filename)
+	env.MainFile.SetValue(MakeString(filename))
 }
 
 /*
@@ -132,7 +133,7 @@ This runs after invariant initialization, which includes calling
 	initializations must be reflected in gen_code/gen_code.go.
 */
 func (env *Env) SetFilename(obj Object) {
-	env.file.Value = obj
+	env.file.SetValue(obj)
 }
 
 func (env *Env) IsStdIn(obj Object) bool {
@@ -144,7 +145,7 @@ func (env *Env) CurrentNamespace() *Namespace {
 }
 
 func (env *Env) SetCurrentNamespace(ns *Namespace) {
-	env.ns.Value = ns
+	env.ns.SetValue(ns)
 }
 
 func (env *Env) EnsureSymbolIsNamespace(sym Symbol) *Namespace {
@@ -237,11 +238,17 @@ func (env *Env) ResolveSymbol(s Symbol) Symbol {
 			if ns != nil {
 				ns.isUsed = true
 				ns.isGloballyUsed = true
+				if refsTarget != nil {
+					trackReference(ns.mappings[s.name], s, "reference")
+				}
 			}
 			return s
 		}
 		ns.isUsed = true
 		ns.isGloballyUsed = true
+		if refsTarget != nil {
+			trackReference(ns.mappings[s.name], s, "reference")
+		}
 		return Symbol{
 			name: s.name,
 			ns:   ns.Name.name,
@@ -254,6 +261,7 @@ func (env *Env) ResolveSymbol(s Symbol) Symbol {
 			ns:   currentNs.Name.name,
 		}
 	}
+	trackReference(vr, s, "reference")
 	vr.isUsed = true
 	vr.isGloballyUsed = true
 	vr.ns.isUsed = true
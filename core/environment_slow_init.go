@@ -46,6 +46,10 @@ func NewEnv() *Env {
 	return res
 }
 
+func loadCoreData() {
+	// gen_code loads the core namespaces itself.
+}
+
 func (env *Env) ReferCoreToUser() {
 	env.FindNamespace(MakeSymbol("user")).ReferAll(env.CoreNamespace)
 }
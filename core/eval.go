@@ -22,6 +22,11 @@ type (
 		rt   *Runtime
 		hash uint32
 	}
+	// StackOverflowError is raised when calls of Joker functions are
+	// nested deeper than allowed.
+	StackOverflowError struct {
+		EvalError
+	}
 	Frame struct {
 		traceable Traceable
 	}
@@ -29,14 +34,27 @@ type (
 		frames []Frame
 	}
 	Runtime struct {
-		callstack   *Callstack
-		currentExpr Expr
-		GIL         sync.Mutex
+		callstack    *Callstack
+		currentExpr  Expr
+		GIL          sync.Mutex
+		budget       *evalBudget
+		maxCallDepth int
+		compile      bool
 	}
 )
 
+// DefaultMaxCallDepth is the default limit on the depth of nested
+// calls of Joker functions. It is low enough for evaluation to fail
+// with a (catchable) StackOverflowError well before the Go runtime
+// aborts the process because the goroutine stack is exhausted.
+const DefaultMaxCallDepth = 50000
+
+// Stacktraces show at most this many frames from each end of the callstack.
+const stacktraceEndFrames = 20
+
 var RT *Runtime = &Runtime{
-	callstack: &Callstack{frames: make([]Frame, 0, 50)},
+	callstack:    &Callstack{frames: make([]Frame, 0, 50)},
+	maxCallDepth: DefaultMaxCallDepth,
 }
 
 func (rt *Runtime) clone() *Runtime {
@@ -57,6 +75,10 @@ func (rt *Runtime) NewError(msg string) *EvalError {
 	return res
 }
 
+func (rt *Runtime) newStackOverflowError(maxDepth int) *StackOverflowError {
+	return &StackOverflowError{EvalError: *rt.NewError(fmt.Sprintf("Evaluation exceeded the limit of %d nested calls", maxDepth))}
+}
+
 func (rt *Runtime) NewArgTypeError(index int, obj Object, expectedType string) *EvalError {
 	name := rt.currentExpr.(Traceable).Name()
 	return rt.NewError(fmt.Sprintf("Arg[%d] of %s must have type %s, got %s", index, name, expectedType, obj.GetType().ToString(false)))
@@ -80,19 +102,32 @@ func (rt *Runtime) stacktrace() string {
 	if rt.currentExpr != nil {
 		pos = rt.currentExpr.Pos()
 	}
-	name := "global"
-	for _, f := range rt.callstack.frames {
-		framePos := f.traceable.Pos()
-		b.WriteString(fmt.Sprintf("  %s %s:%d:%d\n", name, framePos.Filename(), framePos.startLine, framePos.startColumn))
-		name = f.traceable.Name()
-		if strings.HasPrefix(name, "#'") {
-			name = name[2:]
+	frames := rt.callstack.frames
+	for i := 0; i < len(frames); i++ {
+		if i == stacktraceEndFrames && len(frames) > 2*stacktraceEndFrames+1 {
+			omitted := len(frames) - 2*stacktraceEndFrames
+			b.WriteString(fmt.Sprintf("  ... (%d frames omitted)\n", omitted))
+			i += omitted
 		}
+		name := "global"
+		if i > 0 {
+			name = frameName(frames[i-1])
+		}
+		framePos := frames[i].traceable.Pos()
+		b.WriteString(fmt.Sprintf("  %s %s:%d:%d\n", name, framePos.Filename(), framePos.startLine, framePos.startColumn))
+	}
+	name := "global"
+	if len(frames) > 0 {
+		name = frameName(frames[len(frames)-1])
 	}
 	b.WriteString(fmt.Sprintf("  %s %s:%d:%d", name, pos.Filename(), pos.startLine, pos.startColumn))
 	return b.String()
 }
 
+func frameName(f Frame) string {
+	return strings.TrimPrefix(f.traceable.Name(), "#'")
+}
+
 func (rt *Runtime) pushFrame() {
 	// TODO: this is all wrong. We cannot rely on
 	// currentExpr for stacktraces. Instead, each Callable
@@ -103,9 +138,22 @@ func (rt *Runtime) pushFrame() {
 	} else {
 		tr = &CallExpr{}
 	}
+	if rt.maxCallDepth > 0 && len(rt.callstack.frames) >= rt.maxCallDepth {
+		panic(rt.newStackOverflowError(rt.maxCallDepth))
+	}
+	if rt.budget != nil {
+		rt.budget.enterCall()
+	}
 	rt.callstack.pushFrame(Frame{traceable: tr})
 }
 
+// SetMaxCallDepth limits the depth of nested calls of Joker functions
+// to n; a call nested deeper fails with a StackOverflowError. Zero means
+// no limit, in which case deep recursion can crash the process.
+func SetMaxCallDepth(n int) {
+	RT.maxCallDepth = n
+}
+
 func (rt *Runtime) popFrame() {
 	rt.callstack.popFrame()
 }
@@ -114,6 +162,9 @@ func Eval(expr Expr, env *LocalEnv) Object {
 	parentExpr := RT.currentExpr
 	RT.currentExpr = expr
 	defer (func() { RT.currentExpr = parentExpr })()
+	if RT.budget != nil {
+		RT.budget.step()
+	}
 	return expr.Eval(env)
 }
 
@@ -121,6 +172,9 @@ func EvalMaybeAutoDeref(expr Expr, env *LocalEnv, doAutoDeref bool) Object {
 	parentExpr := RT.currentExpr
 	RT.currentExpr = expr
 	defer (func() { RT.currentExpr = parentExpr })()
+	if RT.budget != nil {
+		RT.budget.step()
+	}
 	if v, yes := expr.(*VarRefExpr); yes {
 		return v.vr.Resolve(doAutoDeref)
 	}
@@ -191,6 +245,22 @@ func (err *EvalError) Message() Object {
 	return MakeString(err.msg)
 }
 
+func (err *StackOverflowError) TypeToString(escape bool) string {
+	return err.GetType().ToString(escape)
+}
+
+func (err *StackOverflowError) Equals(other interface{}) bool {
+	return err == other
+}
+
+func (err *StackOverflowError) GetType() *Type {
+	return TYPE.StackOverflowError
+}
+
+func (err *StackOverflowError) WithInfo(info *ObjectInfo) Object {
+	return err
+}
+
 func (err *EvalError) Error() string {
 	pos := err.pos
 	if len(err.rt.callstack.frames) > 0 && !LINTER_MODE {
@@ -271,8 +341,13 @@ func (expr *SetExpr) Eval(env *LocalEnv) Object {
 
 func (expr *DefExpr) Eval(env *LocalEnv) Object {
 	if expr.value != nil {
-		expr.vr.Value = Eval(expr.value, env)
+		expr.vr.SetValue(Eval(expr.value, env))
 	}
+	return expr.define(env)
+}
+
+// define sets the metadata of the var defined by expr.
+func (expr *DefExpr) define(env *LocalEnv) Object {
 	meta := EmptyArrayMap()
 	meta.Add(KEYWORDS.line, Int{I: expr.startLine})
 	meta.Add(KEYWORDS.column, Int{I: expr.startColumn})
@@ -305,6 +380,7 @@ func evalSeq(exprs []Expr, env *LocalEnv) []Object {
 }
 
 func (expr *CallExpr) Eval(env *LocalEnv) Object {
+	CheckInterrupt()
 	callable := Eval(expr.callable, env)
 	switch callable := callable.(type) {
 	case Callable:
@@ -336,7 +412,10 @@ func (expr *CallExpr) Name() string {
 }
 
 func (expr *ThrowExpr) Eval(env *LocalEnv) Object {
-	e := Eval(expr.e, env)
+	return throw(Eval(expr.e, env))
+}
+
+func throw(e Object) Object {
 	switch e.(type) {
 	case Error:
 		panic(e)
@@ -541,6 +620,7 @@ loop:
 	default:
 		return res
 	case RecurBindings:
+		CheckInterrupt()
 		env = env.replaceFrame(res)
 		goto loop
 	}
@@ -602,7 +682,7 @@ func TryEval(expr Expr) (obj Object, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch r.(type) {
-			case *EvalError:
+			case *EvalError, *StackOverflowError:
 				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
@@ -611,7 +691,7 @@ func TryEval(expr Expr) (obj Object, err error) {
 			}
 		}
 	}()
-	return Eval(expr, nil), nil
+	return EvalTopLevel(expr), nil
 }
 
 func PanicOnErr(err error) {
@@ -1,5 +1,9 @@
 package core
 
+import (
+	"strings"
+)
+
 func (expr *LiteralExpr) InferType() *Type {
 	if expr.isSurrogate {
 		return nil
@@ -72,6 +76,9 @@ func (expr *SetExpr) Dump(pos bool) Map {
 }
 
 func (expr *IfExpr) InferType() *Type {
+	if t := expr.positive.InferType(); t != nil && t == expr.negative.InferType() {
+		return t
+	}
 	return nil
 }
 
@@ -109,7 +116,11 @@ func (expr *CallExpr) InferType() *Type {
 				return arity.taggedType
 			}
 		}
-		return callableExpr.vr.taggedType
+		if callableExpr.vr.taggedType != nil {
+			return callableExpr.vr.taggedType
+		}
+		// Vars of std libraries, implemented in Go, are tagged in their metadata.
+		return getTaggedType(callableExpr.vr)
 	}
 	return nil
 }
@@ -205,6 +216,64 @@ func typeOfLast(exprs []Expr) *Type {
 	return nil
 }
 
+func lastExpr(exprs []Expr) Expr {
+	if n := len(exprs); n > 0 {
+		return exprs[n-1]
+	}
+	return nil
+}
+
+// inferenceChain returns the locals, vars and, finally, the call,
+// literal or type tag that the type of expr was inferred from.
+func inferenceChain(expr Expr) []string {
+	switch expr := expr.(type) {
+	case *BindingExpr:
+		if expr.binding.value != nil {
+			return append([]string{expr.binding.name.ToString(false)}, inferenceChain(expr.binding.value)...)
+		}
+	case *VarRefExpr:
+		if expr.vr.taggedType != nil {
+			return []string{expr.vr.Name(), "^" + expr.vr.taggedType.ToString(false)}
+		}
+		if expr.vr.expr != nil && !expr.vr.isDynamic {
+			return append([]string{expr.vr.Name()}, inferenceChain(expr.vr.expr)...)
+		}
+	case *CallExpr:
+		return []string{"(" + expr.Name() + " ...)"}
+	case *LiteralExpr:
+		return []string{expr.obj.ToString(true)}
+	case *VectorExpr, *MapExpr, *SetExpr:
+		return []string{expr.InferType().ToString(false) + " literal"}
+	case *MetaExpr:
+		return inferenceChain(expr.expr)
+	case *IfExpr:
+		positive, negative := inferenceChain(expr.positive), inferenceChain(expr.negative)
+		if strings.Join(positive, " ") == strings.Join(negative, " ") {
+			return positive
+		}
+		return []string{"(if ...)"}
+	case *DoExpr:
+		return inferenceChain(lastExpr(expr.body))
+	case *LetExpr:
+		return inferenceChain(lastExpr(expr.body))
+	case *LoopExpr:
+		return inferenceChain(lastExpr(expr.body))
+	case *TryExpr:
+		return inferenceChain(lastExpr(expr.body))
+	}
+	return nil
+}
+
+// inferenceChainString describes how the type of expr was inferred,
+// unless it's evident, i.e. expr is a call or a literal.
+func inferenceChainString(expr Expr) string {
+	chain := inferenceChain(expr)
+	if len(chain) < 2 {
+		return ""
+	}
+	return " (inferred from " + strings.Join(chain, " <- ") + ")"
+}
+
 func (expr *DoExpr) InferType() *Type {
 	return typeOfLast(expr.body)
 }
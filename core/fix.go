@@ -0,0 +1,549 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Fixing lint warnings in place (--lint --fix). While linting, the
+// parser records the warnings that have mechanical fixes. The file is
+// then read again in format mode, which keeps comments and exact
+// positions of all forms, and the fixes are applied as edits of the
+// source text, so the rest of the file is left as is.
+//
+// Some warnings have no safe fix and are only listed in the summary:
+// moving an inline def to the top level would change when (and whether)
+// it is evaluated and what it refers to.
+
+type (
+	lintFixKind int
+	lintFix     struct {
+		kind lintFixKind
+		pos  Position
+		name string
+	}
+	fixNote struct {
+		pos  Position
+		desc string
+		// Whether the note is about a fixed warning (rather than, e.g., sorting).
+		warning bool
+		// Whether the warning was left as is.
+		skipped bool
+	}
+	textEdit struct {
+		start, end int
+		text       string
+		notes      []fixNote
+	}
+	sourceText struct {
+		src        string
+		lineStarts []int
+		// Forms read in format mode, by their start line and column.
+		forms map[[2]int]Object
+		// Forms containing the forms above, by the same key.
+		parents map[[2]int]Object
+		// Lines (numbers) that continue a multi-line string.
+		stringLines map[int]bool
+	}
+)
+
+const (
+	FIX_REDUNDANT_DO lintFixKind = iota
+	FIX_UNUSED_BINDING
+	FIX_UNUSED_NAMESPACE
+	FIX_INLINE_DEF
+)
+
+var (
+	LINT_FIX_MODE bool
+	lintFixes     []lintFix
+)
+
+func addLintFix(kind lintFixKind, pos Position, name string) {
+	if LINT_FIX_MODE && pos.filename != nil && pos.startLine != 0 {
+		lintFixes = append(lintFixes, lintFix{kind: kind, pos: pos, name: name})
+	}
+}
+
+func newSourceText(src string) *sourceText {
+	st := &sourceText{src: src, lineStarts: []int{0}, forms: make(map[[2]int]Object), parents: make(map[[2]int]Object), stringLines: make(map[int]bool)}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			st.lineStarts = append(st.lineStarts, i+1)
+		}
+	}
+	return st
+}
+
+// offset returns the byte offset of the given (1-based) line and column,
+// which counts runes, as the reader does.
+func (st *sourceText) offset(line, column int) (int, bool) {
+	if line < 1 || line > len(st.lineStarts) || column < 1 {
+		return 0, false
+	}
+	offset := st.lineStarts[line-1]
+	for i := 1; i < column; i++ {
+		if offset >= len(st.src) || st.src[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(st.src[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// span returns the byte offsets of the start and (exclusive) end of obj,
+// including its prefix (such as ' or @), if any.
+func (st *sourceText) span(obj Object) (int, int, bool) {
+	info := obj.GetInfo()
+	if info == nil {
+		return 0, 0, false
+	}
+	start, ok := st.offset(info.startLine, info.startColumn)
+	if !ok {
+		return 0, 0, false
+	}
+	end, ok := st.offset(info.endLine, info.endColumn)
+	if !ok || end >= len(st.src) {
+		return 0, 0, false
+	}
+	_, size := utf8.DecodeRuneInString(st.src[end:])
+	end += size
+	if prefix := info.prefix; prefix != "" {
+		// Depending on the form, the position either includes the prefix or follows it.
+		switch {
+		case strings.HasPrefix(st.src[start:], prefix):
+		case start >= len(prefix) && st.src[start-len(prefix):start] == prefix:
+			start -= len(prefix)
+		default:
+			return 0, 0, false
+		}
+	}
+	return start, end, true
+}
+
+func formChildren(obj Object) []Object {
+	switch obj := obj.(type) {
+	case Seq:
+		return ToSlice(obj)
+	case Vec:
+		return ToSlice(obj.Seq())
+	case Map:
+		var res []Object
+		for iter := obj.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			res = append(res, p.Key)
+			if !isComment(p.Key) {
+				res = append(res, p.Value)
+			}
+		}
+		return res
+	case Set:
+		return ToSlice(obj.(Seqable).Seq())
+	}
+	return nil
+}
+
+func formKey(obj Object) ([2]int, bool) {
+	info := obj.GetInfo()
+	if info == nil {
+		return [2]int{}, false
+	}
+	return [2]int{info.startLine, info.startColumn}, true
+}
+
+func (st *sourceText) index(obj Object, parent Object) {
+	if info := obj.GetInfo(); info != nil {
+		key := [2]int{info.startLine, info.startColumn}
+		st.forms[key] = obj
+		if parent != nil {
+			st.parents[key] = parent
+		}
+		switch obj.(type) {
+		case String, *Regex:
+			for line := info.startLine + 1; line <= info.endLine; line++ {
+				st.stringLines[line] = true
+			}
+		}
+	}
+	for _, child := range formChildren(obj) {
+		st.index(child, obj)
+	}
+}
+
+func (st *sourceText) formAt(pos Position) Object {
+	return st.forms[[2]int{pos.startLine, pos.startColumn}]
+}
+
+// readForms reads the source in format mode.
+func (st *sourceText) readForms(filename string) ([]Object, error) {
+	formatMode, threshold := FORMAT_MODE, HASHMAP_THRESHOLD
+	FORMAT_MODE, HASHMAP_THRESHOLD = true, 100000
+	defer func() {
+		FORMAT_MODE, HASHMAP_THRESHOLD = formatMode, threshold
+	}()
+	reader := NewReader(strings.NewReader(st.src), filename)
+	var res []Object
+	for {
+		obj, err := TryRead(reader)
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		st.index(obj, nil)
+		res = append(res, obj)
+	}
+}
+
+func isSymbolNamed(obj Object, name string) bool {
+	sym, ok := obj.(Symbol)
+	return ok && sym.ns == nil && *sym.name == name
+}
+
+// redundantDoEdits removes the (do and ) around the body of a do form.
+func (st *sourceText) redundantDoEdits(fix lintFix) []textEdit {
+	seq, ok := st.formAt(fix.pos).(Seq)
+	if !ok {
+		return nil
+	}
+	children := ToSlice(seq)
+	if len(children) < 2 || !isSymbolNamed(children[0], "do") || seq.GetInfo().prefix != "" {
+		return nil
+	}
+	hasBody := false
+	for _, child := range children[1:] {
+		if !isComment(child) {
+			hasBody = true
+		}
+	}
+	if !hasBody {
+		return nil
+	}
+	start, end, ok := st.span(seq)
+	if !ok {
+		return nil
+	}
+	bodyStart, _, ok := st.span(children[1])
+	if !ok {
+		return nil
+	}
+	last := children[len(children)-1]
+	_, bodyEnd, ok := st.span(last)
+	if !ok {
+		return nil
+	}
+	if isComment(last) {
+		// Keep the line break ending the comment.
+		bodyEnd = end - 1
+	}
+	res := []textEdit{
+		{start: start, end: bodyStart, notes: []fixNote{{pos: fix.pos, desc: "removed redundant do form", warning: true}}},
+		{start: bodyEnd, end: end},
+	}
+	// Move the rest of the body left along with its first line.
+	info := seq.GetInfo()
+	shift := children[1].GetInfo().startColumn - info.startColumn
+	for line := children[1].GetInfo().startLine + 1; line <= info.endLine; line++ {
+		if st.stringLines[line] {
+			continue
+		}
+		lineStart := st.lineStarts[line-1]
+		if strings.HasPrefix(st.src[lineStart:], strings.Repeat(" ", shift)) {
+			res = append(res, textEdit{start: lineStart, end: lineStart + shift})
+		}
+	}
+	return res
+}
+
+func (st *sourceText) parentOf(obj Object) Object {
+	if key, ok := formKey(obj); ok {
+		return st.parents[key]
+	}
+	return nil
+}
+
+func isSameForm(a, b Object) bool {
+	ka, ok := formKey(a)
+	kb, ok2 := formKey(b)
+	return ok && ok2 && ka == kb
+}
+
+// removalEdit removes forms[from] through forms[to], which are
+// children of the same form, along with the whitespace separating
+// them from the next (or else previous) child.
+func (st *sourceText) removalEdit(forms []Object, from, to int) (textEdit, bool) {
+	start, _, ok := st.span(forms[from])
+	if !ok {
+		return textEdit{}, false
+	}
+	_, end, ok := st.span(forms[to])
+	if !ok {
+		return textEdit{}, false
+	}
+	switch {
+	case to+1 < len(forms):
+		end, _, ok = st.span(forms[to+1])
+	case from > 0:
+		_, start, ok = st.span(forms[from-1])
+	}
+	return textEdit{start: start, end: end}, ok
+}
+
+// keysEdits removes an unused binding from the :keys, :strs or :syms
+// vector of a map binding form (where renaming it to _ would
+// destructure the wrong key), along with the whole entry if the
+// binding is the only one in it. The second result is whether the
+// binding is in such a vector.
+func (st *sourceText) keysEdits(obj Object, fix lintFix) ([]textEdit, bool) {
+	vec, ok := st.parentOf(obj).(Vec)
+	if !ok {
+		return nil, false
+	}
+	m, ok := st.parentOf(vec).(Map)
+	if !ok {
+		return nil, false
+	}
+	entries := formChildren(m)
+	for i := 1; i < len(entries); i++ {
+		if !isSameForm(entries[i], vec) {
+			continue
+		}
+		kw, ok := entries[i-1].(Keyword)
+		if !ok || kw.ns != nil || (*kw.name != "keys" && *kw.name != "strs" && *kw.name != "syms") {
+			return nil, false
+		}
+		var e textEdit
+		items := ToSlice(vec.Seq())
+		if len(items) == 1 {
+			e, ok = st.removalEdit(entries, i-1, i)
+		} else {
+			for j, item := range items {
+				if isSameForm(item, obj) {
+					e, ok = st.removalEdit(items, j, j)
+				}
+			}
+		}
+		if !ok {
+			return nil, true
+		}
+		e.notes = []fixNote{{pos: fix.pos, desc: "removed unused binding " + fix.name, warning: true}}
+		return []textEdit{e}, true
+	}
+	return nil, false
+}
+
+// unusedBindingEdit renames an unused binding to _.
+func (st *sourceText) unusedBindingEdit(fix lintFix) []textEdit {
+	obj := st.formAt(fix.pos)
+	if !isSymbolNamed(obj, fix.name) || obj.GetInfo().prefix != "" {
+		return nil
+	}
+	if edits, ok := st.keysEdits(obj, fix); ok {
+		return edits
+	}
+	start, end, ok := st.span(obj)
+	if !ok || st.src[start:end] != fix.name {
+		return nil
+	}
+	return []textEdit{{start: start, end: end, text: "_", notes: []fixNote{{pos: fix.pos, desc: "renamed unused binding " + fix.name + " to _", warning: true}}}}
+}
+
+// requireEdit removes the libspecs of unused namespaces from the
+// (:require ...) clause of an ns form (or the whole clause if none is
+// left) and sorts the remaining ones, as the formatter does.
+func (st *sourceText) requireEdit(clause Seq, unused map[[2]int]lintFix) *textEdit {
+	children := ToSlice(clause)
+	if len(children) < 2 {
+		return nil
+	}
+	libspecs := children[1:]
+	sortable := true
+	var kept []int
+	var removed []lintFix
+	for i, libspec := range libspecs {
+		info := libspec.GetInfo()
+		if info == nil || info.prefix != "" {
+			return nil
+		}
+		if isComment(libspec) {
+			sortable = false
+		}
+		name := libspec
+		if v, ok := libspec.(Vec); ok && v.Count() > 0 {
+			name = v.At(0)
+		}
+		if _, ok := name.(Symbol); ok {
+			if fix, ok := unused[[2]int{name.GetInfo().startLine, name.GetInfo().startColumn}]; ok {
+				removed = append(removed, fix)
+				continue
+			}
+		}
+		kept = append(kept, i)
+	}
+	sorted := make([]int, len(kept))
+	copy(sorted, kept)
+	if sortable {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return RequireSort(libspecs).Less(sorted[i], sorted[j])
+		})
+	}
+	isSorted := true
+	for i := range sorted {
+		if sorted[i] != kept[i] {
+			isSorted = false
+		}
+	}
+	if len(removed) == 0 && isSorted {
+		return nil
+	}
+	if len(kept) > 0 && isComment(libspecs[kept[len(kept)-1]]) && kept[len(kept)-1] != len(libspecs)-1 {
+		// The closing paren would end up in the comment.
+		return nil
+	}
+	spans := make([][2]int, len(libspecs))
+	for i, libspec := range libspecs {
+		start, end, ok := st.span(libspec)
+		if !ok {
+			return nil
+		}
+		spans[i] = [2]int{start, end}
+	}
+	start := spans[0][0]
+	end := spans[len(spans)-1][1]
+	var b strings.Builder
+	for i, k := range sorted {
+		if i > 0 {
+			// Keep the whitespace that preceded the i-th kept libspec.
+			b.WriteString(st.src[spans[kept[i]-1][1]:spans[kept[i]][0]])
+		}
+		b.WriteString(st.src[spans[k][0]:spans[k][1]])
+	}
+	e := &textEdit{start: start, end: end, text: b.String()}
+	if len(kept) == 0 {
+		// (:require) with no libspecs fails to load, so remove the clause.
+		ns, ok := st.parentOf(clause).(Seq)
+		if !ok {
+			return nil
+		}
+		forms := ToSlice(ns)
+		for i, form := range forms {
+			if isSameForm(form, clause) {
+				*e, ok = st.removalEdit(forms, i, i)
+			}
+		}
+		if !ok {
+			return nil
+		}
+	}
+	for _, fix := range removed {
+		e.notes = append(e.notes, fixNote{pos: fix.pos, desc: "removed unused namespace " + fix.name, warning: true})
+	}
+	if !isSorted {
+		e.notes = append(e.notes, fixNote{pos: clause.GetInfo().Position, desc: "sorted :require"})
+	}
+	return e
+}
+
+func (st *sourceText) nsEdits(forms []Object, unused map[[2]int]lintFix) []textEdit {
+	var res []textEdit
+	for _, form := range forms {
+		seq, ok := form.(Seq)
+		if !ok || seq.IsEmpty() || !isSymbolNamed(seq.First(), "ns") {
+			continue
+		}
+		for _, clause := range ToSlice(seq.Rest()) {
+			if clause, ok := clause.(Seq); ok && !clause.IsEmpty() && clause.First().Equals(KEYWORDS.require) {
+				if e := st.requireEdit(clause, unused); e != nil {
+					res = append(res, *e)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// FixLintWarnings applies the fixes recorded while linting filename,
+// whose contents are src, and returns the fixed source and a summary
+// of the changes (and of the warnings left as is). Fixed warnings are
+// no longer counted as problems.
+func FixLintWarnings(filename string, src string) (string, []string, error) {
+	fixes := lintFixes
+	lintFixes = nil
+	st := newSourceText(src)
+	forms, err := st.readForms(filename)
+	if err != nil {
+		return src, nil, err
+	}
+	var edits []textEdit
+	var notes []fixNote
+	unused := make(map[[2]int]lintFix)
+	for _, fix := range fixes {
+		if fix.pos.Filename() != filename {
+			continue
+		}
+		switch fix.kind {
+		case FIX_REDUNDANT_DO:
+			edits = append(edits, st.redundantDoEdits(fix)...)
+		case FIX_UNUSED_BINDING:
+			edits = append(edits, st.unusedBindingEdit(fix)...)
+		case FIX_UNUSED_NAMESPACE:
+			unused[[2]int{fix.pos.startLine, fix.pos.startColumn}] = fix
+		case FIX_INLINE_DEF:
+			notes = append(notes, fixNote{pos: fix.pos, desc: "inline def (moving it to the top level would change when it is evaluated)", skipped: true})
+		}
+	}
+	edits = append(edits, st.nsEdits(forms, unused)...)
+
+	// Apply non-overlapping edits (the same warning may be reported more than once).
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+	var b strings.Builder
+	prev := 0
+	var last *textEdit
+	for i, e := range edits {
+		if last != nil && e.start == last.start && e.end == last.end && e.text == last.text {
+			for _, note := range e.notes {
+				if note.warning {
+					PROBLEM_COUNT--
+				}
+			}
+			continue
+		}
+		if e.start < prev {
+			continue
+		}
+		last = &edits[i]
+		b.WriteString(src[prev:e.start])
+		b.WriteString(e.text)
+		prev = e.end
+		for _, note := range e.notes {
+			if note.warning {
+				PROBLEM_COUNT--
+			}
+			notes = append(notes, note)
+		}
+	}
+	b.WriteString(src[prev:])
+	sort.SliceStable(notes, func(i, j int) bool {
+		pi, pj := notes[i].pos, notes[j].pos
+		if pi.startLine != pj.startLine {
+			return pi.startLine < pj.startLine
+		}
+		return pi.startColumn < pj.startColumn
+	})
+	res := make([]string, len(notes))
+	for i, note := range notes {
+		status := "Fixed"
+		if note.skipped {
+			status = "Not fixed"
+		}
+		res[i] = fmt.Sprintf("%s:%d:%d: %s: %s", filename, note.pos.startLine, note.pos.startColumn, status, note.desc)
+	}
+	return b.String(), res, nil
+}
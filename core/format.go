@@ -1,13 +1,314 @@
 package core
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"sort"
+	"strings"
 	"unicode/utf8"
 )
 
+type (
+	indentKind int
+	indentRule struct {
+		kind indentKind
+		n    int
+	}
+	FormatOptions struct {
+		indents          map[string][]indentRule
+		learnedIndents   map[string][]indentRule
+		aliases          map[string]string // From the last ns form formatted.
+		alignMapValues   bool
+		alignLetBindings bool
+	}
+)
+
+const (
+	INDENT_BLOCK indentKind = iota
+	INDENT_INNER
+)
+
+var FORMAT_OPTIONS = FormatOptions{
+	indents:        make(map[string][]indentRule),
+	learnedIndents: make(map[string][]indentRule),
+	aliases:        make(map[string]string),
+}
+
+// parseIndentSpec converts cljfmt-style indentation spec, e.g. [[:block 1]] or
+// [:inner 0], or a :style/indent value (n or :defn), into indentation rules.
+func parseIndentSpec(spec Object) ([]indentRule, error) {
+	switch spec := spec.(type) {
+	case Int:
+		return []indentRule{{kind: INDENT_BLOCK, n: spec.I}}, nil
+	case Keyword:
+		if spec.Equals(MakeKeyword("defn")) {
+			return []indentRule{{kind: INDENT_INNER, n: 0}}, nil
+		}
+	case Vec:
+		if spec.Count() > 0 {
+			if _, ok := spec.At(0).(Keyword); ok {
+				rule, err := parseIndentRule(spec)
+				if err != nil {
+					return nil, err
+				}
+				return []indentRule{rule}, nil
+			}
+		}
+		var res []indentRule
+		for i := 0; i < spec.Count(); i++ {
+			v, ok := spec.At(i).(Vec)
+			if !ok {
+				return nil, errors.New("indent rule must be a vector, got " + spec.At(i).GetType().ToString(false))
+			}
+			rule, err := parseIndentRule(v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, rule)
+		}
+		return res, nil
+	}
+	return nil, errors.New("indent spec must be a vector, an integer or :defn, got " + spec.ToString(false))
+}
+
+func parseIndentRule(v Vec) (indentRule, error) {
+	if v.Count() != 2 {
+		return indentRule{}, errors.New("indent rule must have two elements, got " + v.ToString(false))
+	}
+	n, ok := v.At(1).(Int)
+	if !ok || n.I < 0 {
+		return indentRule{}, errors.New("indent rule argument must be a non-negative integer, got " + v.At(1).ToString(false))
+	}
+	switch {
+	case v.At(0).Equals(MakeKeyword("block")):
+		return indentRule{kind: INDENT_BLOCK, n: n.I}, nil
+	case v.At(0).Equals(MakeKeyword("inner")):
+		if n.I > 1 {
+			return indentRule{}, errors.New(":inner indent depth must be 0 or 1, got " + n.ToString(false))
+		}
+		return indentRule{kind: INDENT_INNER, n: n.I}, nil
+	}
+	return indentRule{}, errors.New("indent rule must start with :block or :inner, got " + v.At(0).ToString(false))
+}
+
+func parseFormatConfig(m Map) error {
+	if ok, v := m.Get(MakeKeyword("indents")); ok {
+		indents, ok := v.(Map)
+		if !ok {
+			return errors.New(":indents value (in :format) must be a map, got " + v.GetType().ToString(false))
+		}
+		for iter := iter(indents.Seq()); iter.HasNext(); {
+			p := iter.Next().(Vec)
+			sym, ok := p.At(0).(Symbol)
+			if !ok {
+				return errors.New(":indents keys (in :format) must be symbols, got " + p.At(0).GetType().ToString(false))
+			}
+			rules, err := parseIndentSpec(p.At(1))
+			if err != nil {
+				return err
+			}
+			FORMAT_OPTIONS.indents[sym.ToString(false)] = rules
+		}
+	}
+	if ok, v := m.Get(MakeKeyword("align-map-values")); ok {
+		FORMAT_OPTIONS.alignMapValues = ToBool(v)
+	}
+	if ok, v := m.Get(MakeKeyword("align-let-bindings")); ok {
+		FORMAT_OPTIONS.alignLetBindings = ToBool(v)
+	}
+	return nil
+}
+
+// learnStyleIndent records :style/indent metadata of a defmacro form
+// so that subsequent calls to that macro are indented accordingly.
+func learnStyleIndent(seq Seq) {
+	var name Symbol
+	found := false
+	for s := seq; !s.IsEmpty(); s = s.Rest() {
+		switch obj := s.First().(type) {
+		case Symbol:
+			if !found && !isComment(obj) {
+				name = obj
+				found = true
+			}
+		case Map:
+			if ok, v := obj.Get(KEYWORDS.styleIndent); ok && found {
+				if rules, err := parseIndentSpec(v); err == nil {
+					FORMAT_OPTIONS.learnedIndents[*name.name] = rules
+				}
+				return
+			} else if ok {
+				// ^{:style/indent n} preceding the name.
+				for s = s.Rest(); !s.IsEmpty(); s = s.Rest() {
+					if sym, ok := s.First().(Symbol); ok && !isComment(sym) {
+						if rules, err := parseIndentSpec(v); err == nil {
+							FORMAT_OPTIONS.learnedIndents[*sym.name] = rules
+						}
+						return
+					}
+				}
+				return
+			}
+		case Vec, Seq:
+			return
+		}
+	}
+}
+
+// learnAliases records the namespace aliases created by seq, an ns
+// form, so that :indents keys match symbols qualified with them.
+func learnAliases(seq Seq) {
+	FORMAT_OPTIONS.aliases = make(map[string]string)
+	for _, clause := range ToSlice(seq) {
+		clause, ok := clause.(Seq)
+		if !ok || clause.IsEmpty() || !clause.First().Equals(KEYWORDS.require) {
+			continue
+		}
+		for _, libspec := range ToSlice(clause.Rest()) {
+			v, ok := libspec.(Vec)
+			if !ok || v.Count() == 0 {
+				continue
+			}
+			lib, ok := v.At(0).(Symbol)
+			if !ok {
+				continue
+			}
+			for i := 1; i < v.Count()-1; i++ {
+				if v.At(i).Equals(MakeKeyword("as")) || v.At(i).Equals(MakeKeyword("as-alias")) {
+					if alias, ok := v.At(i + 1).(Symbol); ok {
+						FORMAT_OPTIONS.aliases[alias.ToString(false)] = lib.ToString(false)
+					}
+				}
+			}
+		}
+	}
+}
+
+func customIndentRules(obj Object) []indentRule {
+	s, ok := obj.(Symbol)
+	if !ok {
+		return nil
+	}
+	if rules, ok := FORMAT_OPTIONS.indents[s.ToString(false)]; ok {
+		return rules
+	}
+	if s.ns != nil {
+		if ns, ok := FORMAT_OPTIONS.aliases[*s.ns]; ok {
+			if rules, ok := FORMAT_OPTIONS.indents[ns+"/"+*s.name]; ok {
+				return rules
+			}
+		}
+	}
+	if rules, ok := FORMAT_OPTIONS.indents[*s.name]; ok {
+		return rules
+	}
+	if rules, ok := FORMAT_OPTIONS.learnedIndents[*s.name]; ok {
+		return rules
+	}
+	if s.ns == nil {
+		if vr, ok := GLOBAL_ENV.CoreNamespace.mappings[s.name]; ok && vr.meta != nil {
+			if ok, v := vr.meta.Get(KEYWORDS.styleIndent); ok {
+				if rules, err := parseIndentSpec(v); err == nil {
+					return rules
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// argsOnFirstLine returns the number of elements of seq
+// that are on the same line as obj.
+func argsOnFirstLine(obj Object, seq Seq) int {
+	cnt := 0
+	for !seq.IsEmpty() && !isNewLine(obj, seq.First()) {
+		obj = seq.First()
+		seq = seq.Rest()
+		cnt++
+	}
+	return cnt
+}
+
+// alignedWidth returns the width to which keys of objs
+// (alternating keys and values) should be padded, and whether
+// objs can be aligned at all: there must be at least two pairs,
+// one pair per line, no comments and no multiline keys.
+func alignedWidth(objs []Object) (int, bool) {
+	if len(objs) < 4 || len(objs)%2 != 0 {
+		return 0, false
+	}
+	width := 0
+	for i := 0; i < len(objs); i += 2 {
+		k, v := objs[i], objs[i+1]
+		if isComment(k) || isComment(v) || isNewLine(k, v) {
+			return 0, false
+		}
+		if i > 0 && !isNewLine(objs[i-1], k) {
+			return 0, false
+		}
+		var b bytes.Buffer
+		n := formatObject(k, 0, &b)
+		if strings.ContainsRune(b.String(), '\n') {
+			return 0, false
+		}
+		if n > width {
+			width = n
+		}
+	}
+	return width, true
+}
+
+// formatMap formats the elements of a map literal, arr (keys and
+// values, except that comments are not followed by values), in braces.
+func formatMap(arr []Object, w io.Writer, indent int) int {
+	ind := indent + 1
+	fmt.Fprint(w, "{")
+	if FORMAT_OPTIONS.alignMapValues {
+		if width, ok := alignedWidth(arr); ok {
+			ind = formatAlignedPairs(arr, width, w, indent+1)
+			fmt.Fprint(w, "}")
+			return ind + 1
+		}
+	}
+	if len(arr) > 0 {
+		for i := 0; i < len(arr)-1; i++ {
+			ind = formatObject(arr[i], ind, w)
+			ind = maybeNewLine(w, arr[i], arr[i+1], indent+1, ind)
+		}
+		ind = formatObject(arr[len(arr)-1], ind, w)
+	}
+	if len(arr) > 0 {
+		if isComment(arr[len(arr)-1]) {
+			fmt.Fprint(w, "\n")
+			writeIndent(w, indent+1)
+			ind = indent + 1
+		}
+	}
+
+	fmt.Fprint(w, "}")
+	return ind + 1
+}
+
+func formatAlignedPairs(objs []Object, width int, w io.Writer, indent int) int {
+	ind := indent
+	for i := 0; i < len(objs); i += 2 {
+		if i > 0 {
+			writeNewLines(w, objs[i-1], objs[i])
+			writeIndent(w, indent)
+			ind = indent
+		}
+		start := ind
+		ind = formatObject(objs[i], ind, w)
+		pad := width - (ind - start) + 1
+		writeIndent(w, pad)
+		ind = formatObject(objs[i+1], ind+pad, w)
+	}
+	return ind
+}
+
 func seqFirst(seq Seq, w io.Writer, indent int) (Seq, int) {
 	if !seq.IsEmpty() {
 		indent = formatObject(seq.First(), indent, w)
@@ -82,6 +383,18 @@ func seqFirstAfterForcedBreak(seq Seq, w io.Writer, indent int) (Seq, Object, in
 }
 
 func formatBindings(v Vec, w io.Writer, indent int) int {
+	if FORMAT_OPTIONS.alignLetBindings {
+		objs := make([]Object, v.Count())
+		for i := range objs {
+			objs[i] = v.At(i)
+		}
+		if width, ok := alignedWidth(objs); ok {
+			fmt.Fprint(w, "[")
+			ind := formatAlignedPairs(objs, width, w, indent+1)
+			fmt.Fprint(w, "]")
+			return ind + 1
+		}
+	}
 	return v.Format(w, indent)
 }
 
@@ -241,7 +554,35 @@ func formatSeqEx(seq Seq, w io.Writer, indent int, formatAsDef bool) int {
 		obj.Equals(SYMBOLS.extendType) {
 		isDefRecord = true
 	}
-	if obj.Equals(SYMBOLS.ns) || isOneAndBodyExpr(obj) {
+	if obj.Equals(SYMBOLS.defmacro) {
+		learnStyleIndent(seq)
+	}
+	if obj.Equals(SYMBOLS.ns) {
+		learnAliases(seq)
+	}
+	if rules := customIndentRules(obj); rules != nil {
+		restIndent = indent + 2
+		for _, rule := range rules {
+			switch {
+			case rule.kind == INDENT_INNER && rule.n == 1:
+				isDefRecord = true
+			case rule.kind == INDENT_BLOCK && argsOnFirstLine(obj, seq) > rule.n:
+				// Too many arguments on the first line: indent as function call.
+				restIndent = indent + 1
+				if !seq.IsEmpty() && !isNewLine(obj, seq.First()) {
+					restIndent = i + 1
+				}
+			}
+		}
+		if obj.Equals(SYMBOLS.let) || obj.Equals(SYMBOLS.loop) {
+			if v, ok := seq.First().(Vec); ok && !isNewLine(obj, v) {
+				fmt.Fprint(w, " ")
+				i = formatBindings(v, w, i+1)
+				prevObj = seq.First()
+				seq = seq.Rest()
+			}
+		}
+	} else if obj.Equals(SYMBOLS.ns) || isOneAndBodyExpr(obj) {
 		seq, prevObj, i = seqFirstAfterSpace(seq, w, i, isDefRecord)
 	} else if obj.Equals(KEYWORDS.require) || obj.Equals(KEYWORDS._import) {
 		seq = sortRequire(seq)
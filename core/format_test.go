@@ -0,0 +1,40 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatHashMapAligned(t *testing.T) {
+	formatMode, threshold, align := FORMAT_MODE, HASHMAP_THRESHOLD, FORMAT_OPTIONS.alignMapValues
+	defer func() {
+		FORMAT_MODE, HASHMAP_THRESHOLD, FORMAT_OPTIONS.alignMapValues = formatMode, threshold, align
+	}()
+	FORMAT_MODE, HASHMAP_THRESHOLD, FORMAT_OPTIONS.alignMapValues = true, 4, true
+
+	src := "{:c 1\n ;; comment\n :a 2\n :bcd 3}"
+	obj, err := TryRead(NewReader(bytes.NewBufferString(src), "<test>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*HashMap); !ok {
+		t.Fatalf("expected a HashMap, got %s", obj.GetType().ToString(false))
+	}
+	var b bytes.Buffer
+	formatObject(obj, 0, &b)
+	// Not aligned because of the comment, but in the original order.
+	if b.String() != src {
+		t.Errorf("expected:\n%s\ngot:\n%s", src, b.String())
+	}
+
+	src = "{:c 1\n :a 2\n :bcd 3}"
+	obj, err = TryRead(NewReader(bytes.NewBufferString(src), "<test>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	formatObject(obj, 0, &b)
+	if expected := "{:c   1\n :a   2\n :bcd 3}"; b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
@@ -758,6 +758,14 @@ func MakeGoReceiver(name string, f func(GoObject, Object) Object, doc, added str
 	return v
 }
 
+// MakeDeprecatedGoReceiver is like MakeGoReceiver, but for a method that
+// is deprecated (per its doc comment, or that of its receiver's type).
+func MakeDeprecatedGoReceiver(name string, f func(GoObject, Object) Object, doc, added string, arglist *Vector) *Var {
+	v := MakeGoReceiver(name, f, doc, added, arglist)
+	v.meta.(*ArrayMap).Add(KEYWORDS.deprecated, Boolean{B: true})
+	return v
+}
+
 func MaybeIs_arrayOfuint8(o Object) ([]uint8, string) {
 	switch obj := o.(type) {
 	case Native:
@@ -2,6 +2,7 @@ package core
 
 import (
 	"io"
+	"sort"
 )
 
 type (
@@ -871,6 +872,30 @@ func (m *HashMap) Pprint(w io.Writer, indent int) int {
 	return pprintMap(m, w, indent)
 }
 
+// Format formats m, a map literal read in format mode, with its keys in
+// the order they were read.
+func (m *HashMap) Format(w io.Writer, indent int) int {
+	var pairs []*Pair
+	for iter := m.Iter(); iter.HasNext(); {
+		pairs = append(pairs, iter.Next())
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key.GetInfo(), pairs[j].Key.GetInfo()
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.startLine < b.startLine || (a.startLine == b.startLine && a.startColumn < b.startColumn)
+	})
+	var arr []Object
+	for _, p := range pairs {
+		arr = append(arr, p.Key)
+		if !isComment(p.Key) {
+			arr = append(arr, p.Value)
+		}
+	}
+	return formatMap(arr, w, indent)
+}
+
 func (m *HashMap) kvreduce(c Callable, init Object) Object {
 	res := init
 	iter := m.Iter()
@@ -0,0 +1,292 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// Interpreter is an isolated Joker environment. Each interpreter has
+	// its own user namespace, its own namespaces created by the code it
+	// evaluates, and its own values of vars in the namespaces it shares
+	// with other interpreters (joker.core and the built-in libraries).
+	//
+	// Interpreters share the GIL, so code evaluated by different
+	// interpreters never runs in parallel. Only one interpreter is
+	// active (has its environment installed in GLOBAL_ENV) at a time;
+	// switching between interpreters happens when no code is running
+	// in the active one, i.e. when all the goroutines running its code
+	// have released the GIL (see ReleaseGIL) or finished.
+	Interpreter struct {
+		env *Env
+		// Values of the shared vars set by the interpreter.
+		values map[*Var]Object
+		closed bool
+		limits EvalLimits
+		budget *evalBudget
+	}
+)
+
+// State guarded by the GIL.
+var (
+	defaultInterpreter *Interpreter
+	activeInterpreter  *Interpreter
+	// Number of goroutines running code in activeInterpreter.
+	activeCount int
+	// Namespaces shared by all interpreters, captured when the first
+	// interpreter is created, and the values of their vars before
+	// any interpreter set them.
+	sharedNamespaces map[*string]*Namespace
+	sharedValues     map[*Var]Object
+)
+
+var interpreterChanged = sync.NewCond(&RT.GIL)
+
+var ErrInterpreterClosed = errors.New("Interpreter is closed")
+
+// DefaultInterpreter returns the interpreter wrapping the environment
+// Joker starts with (the initial value of GLOBAL_ENV).
+// Must be called with the GIL held (or before any code runs).
+func DefaultInterpreter() *Interpreter {
+	if defaultInterpreter == nil {
+		defaultInterpreter = &Interpreter{env: GLOBAL_ENV}
+		activeInterpreter = defaultInterpreter
+	}
+	return defaultInterpreter
+}
+
+// ActiveInterpreter returns the interpreter whose environment is
+// currently installed. Must be called with the GIL held.
+// Natives that run Joker code in goroutines of their own should
+// capture it and call Enter and Leave around that code.
+func ActiveInterpreter() *Interpreter {
+	if activeInterpreter == nil {
+		return DefaultInterpreter()
+	}
+	return activeInterpreter
+}
+
+func captureSharedNamespaces() {
+	if sharedNamespaces != nil {
+		return
+	}
+	env := DefaultInterpreter().env
+	sharedNamespaces = make(map[*string]*Namespace)
+	for name, ns := range env.Namespaces {
+		if ns != env.FindNamespace(MakeSymbol("user")) {
+			sharedNamespaces[name] = ns
+		}
+	}
+	sharedValues = make(map[*Var]Object)
+}
+
+// SetValue sets the value of the var. Vars of the namespaces shared by
+// interpreters have a value in each interpreter, so code that runs in
+// an interpreter must set them with SetValue rather than assign Value.
+func (v *Var) SetValue(val Object) {
+	if v.ns != nil && isBuiltinNamespace(v.ns) {
+		ActiveInterpreter().setValue(v, val)
+	}
+	v.Value = val
+}
+
+// setValue records val as the value of vr, a shared var, in the
+// interpreter.
+func (in *Interpreter) setValue(vr *Var, val Object) {
+	if _, ok := sharedValues[vr]; !ok {
+		sharedValues[vr] = vr.Value
+	}
+	if in.values == nil {
+		in.values = make(map[*Var]Object)
+	}
+	in.values[vr] = val
+}
+
+// NewInterpreter creates an interpreter with a fresh user namespace
+// and vars in shared namespaces set to their initial values.
+// stdin, stdout and stderr become the values of *in*, *out* and *err*.
+func NewInterpreter(stdin io.Reader, stdout, stderr io.Writer) *Interpreter {
+	RT.GIL.Lock()
+	defer RT.GIL.Unlock()
+	if sharedNamespaces == nil {
+		loadCoreData()
+	}
+	captureSharedNamespaces()
+	base := DefaultInterpreter().env
+	env := *base
+	env.Namespaces = make(map[*string]*Namespace, len(sharedNamespaces)+1)
+	for name, ns := range sharedNamespaces {
+		env.Namespaces[name] = ns
+	}
+	base.CoreNamespace.MaybeLazy("NewInterpreter")
+	user := env.EnsureSymbolIsNamespace(MakeSymbol("user"))
+	user.ReferAll(base.CoreNamespace)
+	res := &Interpreter{env: &env}
+	res.setValue(env.ns, user)
+	res.setValue(env.stdin, MakeBufferedReader(stdin))
+	res.setValue(env.stdout, MakeIOWriter(stdout))
+	res.setValue(env.stderr, MakeIOWriter(stderr))
+	res.setValue(env.args, NIL)
+	res.setValue(env.file, NIL)
+	res.setValue(env.MainFile, NIL)
+	return res
+}
+
+// Env returns the environment of the interpreter. Code that uses it
+// must run between Enter and Leave (e.g. within Run).
+func (in *Interpreter) Env() *Env {
+	return in.env
+}
+
+// activate installs the environment of the interpreter and its values
+// of shared vars in place of those of the active interpreter, which
+// must not be running code.
+func (in *Interpreter) activate() {
+	active := ActiveInterpreter()
+	active.budget = RT.budget
+	for vr := range active.values {
+		if _, ok := in.values[vr]; !ok {
+			vr.Value = sharedValues[vr]
+		}
+	}
+	if active.closed {
+		active.values = nil
+	}
+	for vr, v := range in.values {
+		vr.Value = v
+	}
+	GLOBAL_ENV = in.env
+	RT.budget = in.budget
+	activeInterpreter = in
+}
+
+// Enter makes the interpreter active and acquires the GIL, waiting
+// until no code is running in other interpreters. Must be called
+// without holding the GIL and paired with Leave.
+func (in *Interpreter) Enter() {
+	RT.GIL.Lock()
+	for ActiveInterpreter() != in && activeCount > 0 {
+		interpreterChanged.Wait()
+	}
+	if ActiveInterpreter() != in {
+		captureSharedNamespaces()
+		in.activate()
+	}
+	activeCount++
+}
+
+// Leave releases the GIL acquired by Enter.
+func (in *Interpreter) Leave() {
+	activeCount--
+	if activeCount == 0 {
+		interpreterChanged.Broadcast()
+	}
+	RT.GIL.Unlock()
+}
+
+// ReleaseGIL releases the GIL held by code running in the active
+// interpreter, e.g. while a native blocks on a channel or I/O, so
+// that code in other interpreters can run in the meantime. Call Enter
+// on the returned interpreter to reacquire the GIL.
+func ReleaseGIL() *Interpreter {
+	in := ActiveInterpreter()
+	in.Leave()
+	return in
+}
+
+// Run calls f with the interpreter active and returns its result.
+// Joker errors (including panics caused by them) are returned as errors.
+// Run can be called concurrently from multiple goroutines, but not
+// from code already holding the GIL (such as natives).
+func (in *Interpreter) Run(f func() Object) (res Object, err error) {
+	in.Enter()
+	defer in.Leave()
+	if in.closed {
+		return nil, ErrInterpreterClosed
+	}
+	if !in.limits.IsZero() {
+		SetEvalLimits(in.limits)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case ReadError, *ParseError, *EvalError, *StackOverflowError, *ExInfo:
+				err = r.(error)
+			default:
+				panic(fmt.Sprintf("Unrecoverable error %s", strconv.Quote(fmt.Sprintf("%s", r))))
+			}
+		}
+	}()
+	return f(), nil
+}
+
+// Eval reads and evaluates all forms in code, returning the value
+// of the last one.
+func (in *Interpreter) Eval(code string) (Object, error) {
+	return in.Run(func() Object {
+		obj, err := loadReader(NewReader(strings.NewReader(code), "<string>"))
+		if err != nil {
+			panic(err)
+		}
+		return obj
+	})
+}
+
+// LoadFile reads and evaluates all forms in the file, returning
+// the value of the last one.
+func (in *Interpreter) LoadFile(filename string) (Object, error) {
+	return in.Run(func() Object {
+		f, err := os.Open(filename)
+		PanicOnErr(err)
+		defer f.Close()
+		currentFilename := in.env.file.Value
+		defer in.env.SetFilename(currentFilename)
+		abs, err := filepath.Abs(filename)
+		PanicOnErr(err)
+		in.env.SetFilename(MakeString(abs))
+		obj, err := loadReader(NewReader(bufio.NewReader(f), filename))
+		if err != nil {
+			panic(err)
+		}
+		return obj
+	})
+}
+
+// SetLimits subjects code evaluated by each subsequent call to Run
+// (and goroutines it starts) to limits.
+func (in *Interpreter) SetLimits(limits EvalLimits) {
+	RT.GIL.Lock()
+	defer RT.GIL.Unlock()
+	in.limits = limits
+}
+
+// SetSandbox restricts code parsed by the interpreter to the vars sb
+// allows (see Sandbox). A nil sb lifts the restrictions.
+func (in *Interpreter) SetSandbox(sb *Sandbox) {
+	RT.GIL.Lock()
+	defer RT.GIL.Unlock()
+	in.env.SetSandbox(sb)
+}
+
+// Close discards the interpreter. Subsequent calls to Run return
+// ErrInterpreterClosed. The default interpreter cannot be closed.
+func (in *Interpreter) Close() {
+	RT.GIL.Lock()
+	defer RT.GIL.Unlock()
+	if in == DefaultInterpreter() {
+		return
+	}
+	in.closed = true
+	// The values of the active interpreter are reverted when another
+	// interpreter is activated.
+	if in != ActiveInterpreter() {
+		in.values = nil
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestInterpreter(t *testing.T) (*Interpreter, *bytes.Buffer) {
+	var out bytes.Buffer
+	in := NewInterpreter(strings.NewReader(""), &out, &out)
+	t.Cleanup(in.Close)
+	return in, &out
+}
+
+func evalString(t *testing.T, in *Interpreter, code string) string {
+	res, err := in.Eval(code)
+	if err != nil {
+		t.Fatalf("%s: %v", code, err)
+	}
+	return res.ToString(false)
+}
+
+func TestInterpreterIsolation(t *testing.T) {
+	a, outA := newTestInterpreter(t)
+	b, outB := newTestInterpreter(t)
+	evalString(t, a, "(def x 1) (in-ns 'a.other) (joker.core/refer 'joker.core) (def y 2)")
+	evalString(t, b, "(def x 10)")
+	if res := evalString(t, a, "(str *ns* \" \" user/x \" \" y)"); res != "a.other 1 2" {
+		t.Errorf("a: got %s", res)
+	}
+	if res := evalString(t, b, "(str *ns* \" \" x \" \" (find-ns 'a.other))"); res != "user 10 " {
+		t.Errorf("b: got %s", res)
+	}
+	// Shared vars have a value in each interpreter.
+	evalString(t, a, "(var-set #'joker.core/*command-line-args* '(\"a\"))")
+	if res := evalString(t, b, "(pr-str *command-line-args*)"); res != "nil" {
+		t.Errorf("b: got %s", res)
+	}
+	if res := evalString(t, a, "(pr-str *command-line-args*)"); res != "(\"a\")" {
+		t.Errorf("a: got %s", res)
+	}
+	evalString(t, a, "(print :a)")
+	evalString(t, b, "(print :b)")
+	if outA.String() != ":a" || outB.String() != ":b" {
+		t.Errorf("got %q and %q", outA.String(), outB.String())
+	}
+}
+
+func TestParkedGoBlock(t *testing.T) {
+	a, _ := newTestInterpreter(t)
+	b, _ := newTestInterpreter(t)
+	evalString(t, a, "(in-ns 'a.ns) (joker.core/refer 'joker.core) (def c (chan)) (def r (go (str (<! c) \" \" *ns*)))")
+	// Let the go block park on the channel.
+	time.Sleep(100 * time.Millisecond)
+	done := make(chan Object)
+	go func() {
+		res, _ := b.Eval("(in-ns 'b.ns) (joker.core/str joker.core/*ns*)")
+		done <- res
+	}()
+	select {
+	case res := <-done:
+		if res == nil || res.ToString(false) != "b.ns" {
+			t.Errorf("b: got %v", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("b is blocked by a go block parked in a")
+	}
+	// The go block resumes in its own interpreter.
+	if res := evalString(t, a, "(>! c 42) (<! r)"); res != "42 a.ns" {
+		t.Errorf("a: got %s", res)
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Interrupting evaluation, e.g. when the user presses Ctrl-C in the REPL.
+var (
+	interrupted      int32
+	interruptMutex   sync.Mutex
+	interruptChannel = make(chan struct{})
+)
+
+// Interrupt makes evaluation fail with an "Interrupted" error at the
+// next function call or loop iteration, or, if it is blocked on
+// a channel operation or sleep, as soon as possible. Evaluation keeps
+// failing (in all goroutines) until ClearInterrupt is called, so the
+// error cannot be swallowed by a catch clause.
+// Interrupt can be called from any goroutine (e.g. a signal handler).
+func Interrupt() {
+	interruptMutex.Lock()
+	defer interruptMutex.Unlock()
+	if atomic.LoadInt32(&interrupted) == 0 {
+		atomic.StoreInt32(&interrupted, 1)
+		close(interruptChannel)
+	}
+}
+
+// ClearInterrupt lets evaluation proceed after Interrupt, e.g. before
+// evaluating the next REPL form.
+func ClearInterrupt() {
+	interruptMutex.Lock()
+	defer interruptMutex.Unlock()
+	if atomic.LoadInt32(&interrupted) != 0 {
+		atomic.StoreInt32(&interrupted, 0)
+		interruptChannel = make(chan struct{})
+	}
+}
+
+// Interrupted returns a channel that is closed when evaluation is
+// interrupted. Natives that block with the GIL released should select
+// on it and on TimedOut, and call AbortWait once they reacquire the GIL
+// if either is closed.
+func Interrupted() <-chan struct{} {
+	interruptMutex.Lock()
+	defer interruptMutex.Unlock()
+	return interruptChannel
+}
+
+// TimedOut returns a channel that is closed when the evaluation
+// exceeds its timeout (see EvalLimits), or nil if it has none.
+// Must be called with the GIL held.
+func TimedOut() <-chan struct{} {
+	if RT.budget == nil {
+		return nil
+	}
+	return RT.budget.expired
+}
+
+func newInterruptedError() *EvalError {
+	return RT.NewError("Interrupted")
+}
+
+// CheckInterrupt panics with an "Interrupted" error if evaluation has
+// been interrupted.
+func CheckInterrupt() {
+	if atomic.LoadInt32(&interrupted) != 0 {
+		panic(newInterruptedError())
+	}
+}
+
+// AbortWait panics with the error that cut a wait short, i.e. with
+// the timeout error if the evaluation timed out and with an
+// "Interrupted" error otherwise. Must be called with the GIL held.
+func AbortWait() {
+	if RT.budget != nil {
+		RT.budget.checkTimeout()
+	}
+	panic(newInterruptedError())
+}
+
+// Sleep pauses the calling goroutine for at least d, releasing the
+// GIL (which must be held) in the meantime. Interrupting evaluation,
+// or its timing out, cuts the pause short.
+func Sleep(d time.Duration) {
+	interrupt, timeout := Interrupted(), TimedOut()
+	t := time.NewTimer(d)
+	defer t.Stop()
+	in := ReleaseGIL()
+	select {
+	case <-t.C:
+		in.Enter()
+	case <-interrupt:
+		in.Enter()
+		AbortWait()
+	case <-timeout:
+		in.Enter()
+		AbortWait()
+	}
+}
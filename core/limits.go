@@ -0,0 +1,132 @@
+package core
+
+import (
+	"fmt"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// EvalLimits bound the resources an evaluation may use.
+	// Zero values mean no limit.
+	EvalLimits struct {
+		// Wall-clock time.
+		Timeout time.Duration
+		// Number of expressions evaluated.
+		MaxSteps int
+		// Depth of nested calls of Joker functions, counted from where
+		// the evaluation starts. Exceeding it raises a StackOverflowError,
+		// like exceeding the process-wide limit (see SetMaxCallDepth).
+		MaxCallDepth int
+		// Bytes allocated on the heap. As Go doesn't track allocations
+		// per goroutine, this counts allocations made by the whole
+		// program during the evaluation, checked every few hundred steps.
+		MaxAlloc uint64
+	}
+
+	// evalBudget tracks the resources used by an evaluation subject
+	// to limits.
+	evalBudget struct {
+		limits    EvalLimits
+		steps     int
+		baseDepth int
+		baseAlloc uint64
+		timedOut  int32
+		timer     *time.Timer
+		// Closed when the evaluation times out, to cut blocking
+		// calls short.
+		expired chan struct{}
+		// Set once a limit other than the call depth is exceeded:
+		// the evaluation is then aborted at every subsequent step,
+		// so the error reaches the top level even if caught.
+		exceeded string
+	}
+)
+
+const allocCheckInterval = 256
+
+var allocMetric = []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+
+func allocatedBytes() uint64 {
+	metrics.Read(allocMetric)
+	if allocMetric[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return allocMetric[0].Value.Uint64()
+}
+
+// IsZero reports whether limits impose no limit.
+func (limits EvalLimits) IsZero() bool {
+	return limits == EvalLimits{}
+}
+
+func newEvalBudget(limits EvalLimits) *evalBudget {
+	b := &evalBudget{
+		limits:    limits,
+		baseDepth: len(RT.callstack.frames),
+	}
+	if limits.MaxAlloc > 0 {
+		b.baseAlloc = allocatedBytes()
+	}
+	if limits.Timeout > 0 {
+		b.expired = make(chan struct{})
+		b.timer = time.AfterFunc(limits.Timeout, func() {
+			atomic.StoreInt32(&b.timedOut, 1)
+			close(b.expired)
+		})
+	}
+	return b
+}
+
+func (b *evalBudget) stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+func (b *evalBudget) exceed(msg string) {
+	b.exceeded = msg
+	panic(RT.NewError(msg))
+}
+
+// step is called before evaluating each expression.
+func (b *evalBudget) step() {
+	if b.exceeded != "" {
+		panic(RT.NewError(b.exceeded))
+	}
+	b.steps++
+	b.checkTimeout()
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		b.exceed(fmt.Sprintf("Evaluation exceeded the limit of %d steps", b.limits.MaxSteps))
+	}
+	if b.limits.MaxAlloc > 0 && b.steps%allocCheckInterval == 0 && allocatedBytes()-b.baseAlloc > b.limits.MaxAlloc {
+		b.exceed(fmt.Sprintf("Evaluation exceeded the limit of %d allocated bytes", b.limits.MaxAlloc))
+	}
+}
+
+func (b *evalBudget) checkTimeout() {
+	if atomic.LoadInt32(&b.timedOut) != 0 {
+		b.exceed(fmt.Sprintf("Evaluation timed out after %s", b.limits.Timeout))
+	}
+}
+
+// enterCall is called when a Joker function is called.
+func (b *evalBudget) enterCall() {
+	if b.limits.MaxCallDepth > 0 && len(RT.callstack.frames)-b.baseDepth > b.limits.MaxCallDepth {
+		panic(RT.newStackOverflowError(b.limits.MaxCallDepth))
+	}
+}
+
+// SetEvalLimits subjects subsequent evaluation to limits, starting
+// now. Must be called with the GIL held (e.g. before evaluating
+// a script or a REPL form).
+func SetEvalLimits(limits EvalLimits) {
+	if RT.budget != nil {
+		RT.budget.stop()
+		RT.budget = nil
+	}
+	if !limits.IsZero() {
+		RT.budget = newEvalBudget(limits)
+	}
+}
@@ -0,0 +1,461 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// On-disk cache of lint results (see --lint-cache). The cache has an
+// entry for each linted file, with the warnings printed for it and what
+// it uses, and the interface of each namespace defined in linted files:
+// its vars, their arities, types, whether they are macros and their
+// deprecation. Before linting a file, the interfaces of the namespaces it
+// requires are restored from the cache, so that calls of their vars are
+// checked as if the files were linted together. An entry is valid as
+// long as its key is: the key covers the file, the config and the
+// cached interfaces of the namespaces the file requires, so the file is
+// linted again if any of them changes.
+
+type (
+	cachedArity struct {
+		Args     []string `json:"args"`
+		ArgTags  []string `json:"argTags,omitempty"`
+		Variadic bool     `json:"variadic,omitempty"`
+		Tag      string   `json:"tag,omitempty"`
+	}
+	cachedVar struct {
+		Name       string        `json:"name"`
+		Macro      bool          `json:"macro,omitempty"`
+		Private    bool          `json:"private,omitempty"`
+		Dynamic    bool          `json:"dynamic,omitempty"`
+		Tag        string        `json:"tag,omitempty"`
+		Arities    []cachedArity `json:"arities,omitempty"`
+		Literal    string        `json:"literal,omitempty"`
+		Deprecated string        `json:"deprecated,omitempty"`
+	}
+	cachedNamespace struct {
+		Deprecated string      `json:"deprecated,omitempty"`
+		Vars       []cachedVar `json:"vars"`
+	}
+	cachedPosition struct {
+		Name   string `json:"name"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	}
+	LintCacheEntry struct {
+		Key      string `json:"key"`
+		Output   string `json:"output"`
+		Problems int    `json:"problems"`
+		// Namespaces positioned in the file and public vars defined in
+		// it that it doesn't use, i.e. that may be globally unused.
+		Namespaces     []cachedPosition `json:"namespaces,omitempty"`
+		Vars           []cachedPosition `json:"vars,omitempty"`
+		UsedNamespaces []string         `json:"usedNamespaces,omitempty"`
+		UsedVars       []string         `json:"usedVars,omitempty"`
+	}
+	LintCache struct {
+		dir string
+	}
+)
+
+func NewLintCache(dir string) (*LintCache, error) {
+	for _, d := range []string{"files", "ns"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0777); err != nil {
+			return nil, err
+		}
+	}
+	return &LintCache{dir: dir}, nil
+}
+
+func hashString(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func (c *LintCache) entryPath(filename string) string {
+	return filepath.Join(c.dir, "files", hashString(filename)+".json")
+}
+
+func (c *LintCache) namespacePath(name string) string {
+	return filepath.Join(c.dir, "ns", name+".json")
+}
+
+func readFileOrEmpty(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// writeFileAtomically writes the JSON encoding of v to filename, so that
+// the linters running in parallel never read a partially written file.
+func writeFileAtomically(filename string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// requiredNamespacesOf returns the namespaces required in src, the
+// source code in filename, other than the ones it defines.
+func requiredNamespacesOf(filename string, src string) []string {
+	deps, _ := FileNamespaceDeps(filename, src)
+	var res []string
+	for _, required := range deps {
+		for _, name := range required {
+			if _, ok := deps[name]; !ok {
+				res = append(res, name)
+			}
+		}
+	}
+	sort.Strings(res)
+	return dedupStrings(res)
+}
+
+// Key returns the key of the entry of filename, with the source code src,
+// linted as dialect with the config found from workingDir (or filename),
+// which must have been read.
+func (c *LintCache) Key(filename string, src string, dialect Dialect, workingDir string) string {
+	h := sha256.New()
+	write := func(s string) {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	write(VERSION)
+	write(fmt.Sprint(dialect))
+	write(filename)
+	write(src)
+	write(readFileOrEmpty(findConfigFile(filename, workingDir, false)))
+	if dir := findConfigFile(filename, workingDir, true); dir != "" {
+		for _, name := range []string{"linter.joke", "linter.cljc", "linter.cljs", "linter.clj"} {
+			write(readFileOrEmpty(filepath.Join(dir, name)))
+		}
+	}
+	var lintNamespaceNames []string
+	for _, sym := range lintRuleNamespaces {
+		lintNamespaceNames = append(lintNamespaceNames, sym.ToString(false))
+	}
+	for _, sym := range lintHooks {
+		lintNamespaceNames = append(lintNamespaceNames, sym.Namespace())
+	}
+	sort.Strings(lintNamespaceNames)
+	for _, name := range dedupStrings(lintNamespaceNames) {
+		write(readFileOrEmpty(lintNamespaceFile(name)))
+	}
+	for _, name := range requiredNamespacesOf(filename, src) {
+		write(name)
+		write(readFileOrEmpty(c.namespacePath(name)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Entry returns the cached entry of filename, or nil if there is none.
+func (c *LintCache) Entry(filename string) *LintCacheEntry {
+	data, err := os.ReadFile(c.entryPath(filename))
+	if err != nil {
+		return nil
+	}
+	var entry LintCacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+func readCachedForm(s string) Object {
+	if s == "" {
+		return nil
+	}
+	obj, err := TryRead(NewReader(strings.NewReader(s), "<lint-cache>"))
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+func readCachedMeta(s string) Map {
+	if m, ok := readCachedForm(s).(Map); ok {
+		return m
+	}
+	return nil
+}
+
+// Restore restores the interfaces of the namespaces required in src, the
+// source code in filename, from the cache. The namespaces that are known
+// otherwise (e.g. from the linter's data) are left as they are.
+func (c *LintCache) Restore(filename string, src string) {
+	for _, name := range requiredNamespacesOf(filename, src) {
+		sym := MakeSymbol(name)
+		if GLOBAL_ENV.FindNamespace(sym) != nil {
+			continue
+		}
+		data, err := os.ReadFile(c.namespacePath(name))
+		if err != nil {
+			continue
+		}
+		var cns cachedNamespace
+		if json.Unmarshal(data, &cns) != nil {
+			continue
+		}
+		ns := GLOBAL_ENV.EnsureSymbolIsNamespace(sym)
+		if m := readCachedMeta(cns.Deprecated); m != nil {
+			ns.meta = m
+		}
+		for _, v := range cns.Vars {
+			restoreVar(ns, v)
+		}
+	}
+}
+
+func typeNamed(name string) *Type {
+	if name == "" {
+		return nil
+	}
+	return TYPES[MakeSymbol(name).name]
+}
+
+func restoreVar(ns *Namespace, v cachedVar) {
+	vr := ns.Intern(MakeSymbol(v.Name))
+	vr.isMacro = v.Macro
+	vr.isPrivate = v.Private
+	vr.isDynamic = v.Dynamic
+	vr.taggedType = typeNamed(v.Tag)
+	if len(v.Arities) > 0 {
+		fn := &FnExpr{}
+		for _, a := range v.Arities {
+			arity := FnArityExpr{taggedType: typeNamed(a.Tag)}
+			for i, name := range a.Args {
+				sym := MakeSymbol(name)
+				if i < len(a.ArgTags) && a.ArgTags[i] != "" {
+					sym = sym.WithMeta(EmptyArrayMap().Assoc(KEYWORDS.tag, MakeString(a.ArgTags[i])).(Map)).(Symbol)
+				}
+				arity.args = append(arity.args, sym)
+			}
+			if a.Variadic {
+				fn.variadic = &arity
+			} else {
+				fn.arities = append(fn.arities, arity)
+			}
+		}
+		vr.expr = fn
+	} else if obj := readCachedForm(v.Literal); obj != nil {
+		vr.expr = NewLiteralExpr(obj)
+	}
+	if m := readCachedMeta(v.Deprecated); m != nil {
+		vr.meta = m
+	}
+}
+
+func tagName(obj Meta) string {
+	if m := obj.GetMeta(); m != nil {
+		if ok, tag := m.Get(KEYWORDS.tag); ok {
+			switch tag := tag.(type) {
+			case Symbol:
+				return tag.ToString(false)
+			case String:
+				return tag.S
+			}
+		}
+	}
+	return ""
+}
+
+func cachedArityOf(arity *FnArityExpr, variadic bool) cachedArity {
+	res := cachedArity{Args: []string{}, Variadic: variadic}
+	hasTags := false
+	for _, arg := range arity.args {
+		res.Args = append(res.Args, arg.ToString(false))
+		tag := tagName(arg)
+		res.ArgTags = append(res.ArgTags, tag)
+		hasTags = hasTags || tag != ""
+	}
+	if !hasTags {
+		res.ArgTags = nil
+	}
+	if arity.taggedType != nil {
+		res.Tag = arity.taggedType.name
+	}
+	return res
+}
+
+// deprecationString returns the deprecation metadata in meta, if any,
+// printed.
+func deprecationString(meta Map) string {
+	if !isDeprecated(meta) {
+		return ""
+	}
+	res := EmptyArrayMap()
+	for _, k := range []Keyword{KEYWORDS.deprecated, KEYWORDS.supersededBy} {
+		if ok, v := meta.Get(k); ok {
+			res.Add(k, v)
+		}
+	}
+	return res.ToString(true)
+}
+
+func cachedVarOf(vr *Var) cachedVar {
+	res := cachedVar{
+		Name:    vr.name.ToString(false),
+		Macro:   vr.isMacro,
+		Private: vr.isPrivate,
+		Dynamic: vr.isDynamic,
+	}
+	if vr.taggedType != nil {
+		res.Tag = vr.taggedType.name
+	}
+	expr := vr.expr
+	if m, ok := expr.(*MetaExpr); ok {
+		expr = m.expr
+	}
+	switch expr := expr.(type) {
+	case *FnExpr:
+		for i := range expr.arities {
+			res.Arities = append(res.Arities, cachedArityOf(&expr.arities[i], false))
+		}
+		if expr.variadic != nil {
+			res.Arities = append(res.Arities, cachedArityOf(expr.variadic, true))
+		}
+	case *LiteralExpr:
+		if !expr.isSurrogate {
+			res.Literal = expr.obj.ToString(true)
+		}
+	}
+	meta, ok := deprecatedVars[vr]
+	if !ok {
+		meta = vr.GetMeta()
+	}
+	res.Deprecated = deprecationString(meta)
+	return res
+}
+
+func cachedNamespaceOf(ns *Namespace) cachedNamespace {
+	res := cachedNamespace{Vars: []cachedVar{}}
+	meta, ok := deprecatedNamespaces[ns.Name.name]
+	if !ok {
+		meta = ns.GetMeta()
+	}
+	res.Deprecated = deprecationString(meta)
+	for _, vr := range ns.mappings {
+		if vr.ns == ns && !vr.isFake {
+			res.Vars = append(res.Vars, cachedVarOf(vr))
+		}
+	}
+	sort.Slice(res.Vars, func(i, j int) bool {
+		return res.Vars[i].Name < res.Vars[j].Name
+	})
+	return res
+}
+
+func cachedPositionOf(name string, info *ObjectInfo) cachedPosition {
+	return cachedPosition{Name: name, Line: info.startLine, Column: info.startColumn}
+}
+
+// Store stores the entry of filename, with the source code src, linted
+// with key, and the interfaces of the namespaces defined in it. output
+// is what linting it printed and problems the number of problems found.
+func (c *LintCache) Store(filename string, src string, key string, output string, problems int) error {
+	deps, _ := FileNamespaceDeps(filename, src)
+	for name := range deps {
+		if ns := GLOBAL_ENV.FindNamespace(MakeSymbol(name)); ns != nil {
+			if err := writeFileAtomically(c.namespacePath(name), cachedNamespaceOf(ns)); err != nil {
+				return err
+			}
+		}
+	}
+	entry := LintCacheEntry{Key: key, Output: output, Problems: problems}
+	for _, ns := range GLOBAL_ENV.Namespaces {
+		if ns == GLOBAL_ENV.CoreNamespace {
+			continue
+		}
+		name := ns.Name.ToString(false)
+		if ns.isGloballyUsed {
+			entry.UsedNamespaces = append(entry.UsedNamespaces, name)
+		} else if info := ns.Name.GetInfo(); info != nil && info.Filename() == filename && !isIgnoredUnusedNamespace(ns) && !isEntryPointNs(ns) {
+			entry.Namespaces = append(entry.Namespaces, cachedPositionOf(name, info))
+		}
+		for _, vr := range ns.mappings {
+			if vr.ns != ns {
+				continue
+			}
+			if vr.isGloballyUsed {
+				entry.UsedVars = append(entry.UsedVars, vr.Name())
+			} else if info := vr.GetInfo(); info != nil && info.Filename() == filename && !vr.isFake && !vr.isPrivate && !isRecordConstructor(vr.name) && !isEntryPointVar(vr) {
+				entry.Vars = append(entry.Vars, cachedPositionOf(vr.Name(), info))
+			}
+		}
+	}
+	sort.Strings(entry.UsedNamespaces)
+	sort.Strings(entry.UsedVars)
+	sort.Slice(entry.Namespaces, func(i, j int) bool { return entry.Namespaces[i].Name < entry.Namespaces[j].Name })
+	sort.Slice(entry.Vars, func(i, j int) bool { return entry.Vars[i].Name < entry.Vars[j].Name })
+	return writeFileAtomically(c.entryPath(filename), entry)
+}
+
+func warnOnGloballyUnusedInEntries(what string, filenames []string, candidates [][]cachedPosition, used [][]string) {
+	positions := make(map[string]Position)
+	for i, cs := range candidates {
+		filename := filenames[i]
+		for _, c := range cs {
+			positions[c.Name] = Position{filename: &filename, startLine: c.Line, startColumn: c.Column}
+		}
+	}
+	for _, us := range used {
+		for _, u := range us {
+			delete(positions, u)
+		}
+	}
+	var names []string
+	for name := range positions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printParseWarning(positions[name], "globally unused "+what+" "+name)
+	}
+}
+
+// WarnOnGloballyUnusedInEntries warns on globally unused namespaces and
+// vars of the cache entries of filenames (nil for the files that failed
+// to be linted), as WarnOnGloballyUnusedNamespaces and
+// WarnOnGloballyUnusedVars do after linting the files together.
+func WarnOnGloballyUnusedInEntries(filenames []string, entries []*LintCacheEntry) {
+	var names []string
+	var namespaces, vars [][]cachedPosition
+	var usedNamespaces, usedVars [][]string
+	for i, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		names = append(names, filenames[i])
+		namespaces = append(namespaces, entry.Namespaces)
+		vars = append(vars, entry.Vars)
+		usedNamespaces = append(usedNamespaces, entry.UsedNamespaces)
+		usedVars = append(usedVars, entry.UsedVars)
+	}
+	warnOnGloballyUnusedInEntries("namespace", names, namespaces, usedNamespaces)
+	warnOnGloballyUnusedInEntries("var", names, vars, usedVars)
+}
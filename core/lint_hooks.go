@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Linting calls of custom macros. The linter can't expand macros it
+// doesn't know (their definitions are not loaded), so it can't check
+// their bodies. The :lint-as map of the .joker config says which
+// known macro a custom macro should be linted as, e.g.
+// {my.db/with-db clojure.core/let}, and :lint-hooks maps macros to
+// Joker functions (loaded like lint rules) that rewrite their calls,
+// as read, into equivalent forms for analysis only.
+
+var (
+	lintAs    map[string]Symbol
+	lintHooks map[string]Symbol
+	// Functions of lintHooks, once loaded.
+	lintHookFns map[string]*Var
+)
+
+// readLintSymbolMap reads the value of a config option that maps
+// namespace-qualified symbols to namespace-qualified symbols.
+func readLintSymbolMap(configFileName string, option string, obj Object) (map[string]Symbol, bool) {
+	m, ok := obj.(Map)
+	if !ok {
+		printConfigError(configFileName, option+" value must be a map, got "+obj.GetType().ToString(false))
+		return nil, false
+	}
+	res := make(map[string]Symbol)
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		from, ok1 := p.Key.(Symbol)
+		to, ok2 := p.Value.(Symbol)
+		if !ok1 || !ok2 || from.ns == nil || to.ns == nil {
+			printConfigError(configFileName, option+" keys and values must be namespace-qualified symbols, got "+p.Key.ToString(true)+" "+p.Value.ToString(true))
+			return nil, false
+		}
+		res[from.ToString(false)] = to
+	}
+	return res, true
+}
+
+func loadLintHooks() {
+	lintHookFns = make(map[string]*Var)
+	for name, sym := range lintHooks {
+		ns := loadLintNamespace(MakeSymbol(sym.Namespace()))
+		if ns == nil {
+			continue
+		}
+		vr, ok := ns.mappings[sym.name]
+		if !ok || vr.ns != ns {
+			fmt.Fprintln(Stderr, "Error loading lint hook for "+name+": ", "unable to resolve "+sym.ToString(false))
+			continue
+		}
+		lintHookFns[name] = vr
+	}
+}
+
+// expandForLinter rewrites seq, a call of a macro configured in
+// :lint-as or :lint-hooks, returning nil if it isn't one.
+func expandForLinter(seq Seq, ctx *ParseContext) Object {
+	if len(lintAs) == 0 && len(lintHookFns) == 0 {
+		return nil
+	}
+	op, ok := seq.First().(Symbol)
+	if !ok || ctx.GetLocalBinding(op) != nil {
+		return nil
+	}
+	name := ctx.GlobalEnv.ResolveSymbol(op).ToString(false)
+	if target, ok := lintAs[name]; ok {
+		if ns := target.Namespace(); ns == "clojure.core" || ns == "cljs.core" {
+			target = Symbol{ns: STRINGS.Intern("joker.core"), name: target.name}
+		}
+		return fixInfo(seq.Rest().Cons(target.WithInfo(op.GetInfo())), seq.GetInfo())
+	}
+	if hook, ok := lintHookFns[name]; ok {
+		res := callLintHook(hook, seq)
+		if s, ok := res.(Seq); ok && !s.IsEmpty() && s.First().Equals(op) {
+			// Expanding it again would never end.
+			return nil
+		}
+		return res
+	}
+	return nil
+}
+
+func callLintHook(hook *Var, seq Seq) (res Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *EvalError, *StackOverflowError, *ExInfo:
+				printParseError(GetPosition(seq), fmt.Sprintf("Lint hook %s failed: %s", hook.Name(), r.(error).Error()))
+				res = nil
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return fixInfo(hook.Call([]Object{seq}), seq.GetInfo())
+}
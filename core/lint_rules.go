@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// User-defined lint rules. The :lint-rules of the .joker config name
+// namespaces, loaded from files relative to the config's directory
+// (house.rules from house/rules.joke), whose public functions are the
+// rules. While linting, each rule is called with every top-level form
+// as parsed, as data (see ExprData, plus the :ns it is parsed in), and
+// returns nil, a diagnostic, or a sequence of them. A diagnostic is
+// a map with a :message and, optionally, the :line and :column it
+// applies to (those of the form by default; any node of the data has
+// them) and the :level (:warning, the default, or :error).
+
+var (
+	lintRuleNamespaces []Symbol
+	lintRulesDir       string
+	lintRules          []*Var
+	// Namespaces of lint rules and hooks, once loaded.
+	lintNamespaces = make(map[*string]*Namespace)
+)
+
+func readLintRulesConfig(configFileName string, rules Object) bool {
+	seq, ok := rules.(Seqable)
+	if !ok {
+		printConfigError(configFileName, ":lint-rules value must be a vector, got "+rules.GetType().ToString(false))
+		return false
+	}
+	for s := seq.Seq(); !s.IsEmpty(); s = s.Rest() {
+		sym, ok := s.First().(Symbol)
+		if !ok {
+			printConfigError(configFileName, ":lint-rules elements must be symbols, got "+s.First().GetType().ToString(false))
+			return false
+		}
+		lintRuleNamespaces = append(lintRuleNamespaces, sym)
+	}
+	lintRulesDir = filepath.Dir(configFileName)
+	return true
+}
+
+// lintNamespaceFile returns the file the namespace of lint rules or
+// hooks named name is loaded from.
+func lintNamespaceFile(name string) string {
+	return filepath.Join(lintRulesDir, strings.ReplaceAll(strings.ReplaceAll(name, ".", "/"), "-", "_")+".joke")
+}
+
+// loadLintNamespace loads the namespace named by sym from the file
+// relative to the config's directory, marking it and its vars as used,
+// since they are not part of the code being linted.
+func loadLintNamespace(sym Symbol) *Namespace {
+	if ns, ok := lintNamespaces[sym.name]; ok {
+		return ns
+	}
+	currentNs := GLOBAL_ENV.CurrentNamespace()
+	defer GLOBAL_ENV.SetCurrentNamespace(currentNs)
+	name := sym.ToString(false)
+	filename := lintNamespaceFile(name)
+	reader, err := NewReaderFromFile(filename)
+	if err != nil {
+		return nil
+	}
+	if ProcessReader(reader, filename, EVAL) != nil {
+		return nil
+	}
+	ns := GLOBAL_ENV.FindNamespace(sym)
+	if ns == nil {
+		fmt.Fprintln(Stderr, "Error loading "+name+": ", filename+" does not define namespace "+name)
+		return nil
+	}
+	lintNamespaces[sym.name] = ns
+	ns.isUsed = true
+	ns.isGloballyUsed = true
+	for _, vr := range ns.mappings {
+		if vr.ns == ns {
+			vr.isUsed = true
+			vr.isGloballyUsed = true
+		}
+	}
+	return ns
+}
+
+// LoadLintRules loads the namespaces of the lint rules and hooks listed
+// in the config.
+func LoadLintRules() {
+	lintRules = nil
+	for _, sym := range lintRuleNamespaces {
+		ns := loadLintNamespace(sym)
+		if ns == nil {
+			continue
+		}
+		var rules []*Var
+		for _, vr := range ns.mappings {
+			if _, ok := vr.Value.(*Fn); ok && vr.ns == ns && !vr.isPrivate {
+				rules = append(rules, vr)
+			}
+		}
+		sort.Slice(rules, func(i, j int) bool {
+			return *rules[i].name.name < *rules[j].name.name
+		})
+		lintRules = append(lintRules, rules...)
+	}
+	loadLintHooks()
+}
+
+// runLintRules calls the user-defined lint rules with expr, a top-level form.
+func runLintRules(expr Expr) {
+	if len(lintRules) == 0 {
+		return
+	}
+	data := ExprData(expr).(Map).Assoc(MakeKeyword("ns"), MakeString(GLOBAL_ENV.CurrentNamespace().Name.ToString(false)))
+	for _, rule := range lintRules {
+		runLintRule(rule, data, expr.Pos())
+	}
+}
+
+func runLintRule(rule *Var, data Object, pos Position) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *EvalError, *StackOverflowError, *ExInfo:
+				printError(pos, fmt.Sprintf("Lint rule %s failed: %s", rule.Name(), r.(error).Error()))
+			default:
+				panic(r)
+			}
+		}
+	}()
+	switch res := rule.Call([]Object{data}).(type) {
+	case Nil:
+	case Map:
+		reportDiagnostic(rule, res, pos)
+	case Seqable:
+		for s := res.Seq(); !s.IsEmpty(); s = s.Rest() {
+			reportDiagnostic(rule, s.First(), pos)
+		}
+	default:
+		printError(pos, fmt.Sprintf("Lint rule %s must return a diagnostic map, a sequence of them, or nil, got %s", rule.Name(), res.GetType().ToString(false)))
+	}
+}
+
+func reportDiagnostic(rule *Var, d Object, pos Position) {
+	m, ok := d.(Map)
+	var message Object = NIL
+	if ok {
+		_, message = m.Get(MakeKeyword("message"))
+	}
+	if _, isString := message.(String); !isString {
+		printError(pos, fmt.Sprintf("Lint rule %s returned a diagnostic without a :message string: %s", rule.Name(), d.ToString(true)))
+		return
+	}
+	if ok, line := m.Get(MakeKeyword("line")); ok {
+		if line, ok := line.(Int); ok {
+			pos.startLine = line.I
+		}
+	}
+	if ok, column := m.Get(MakeKeyword("column")); ok {
+		if column, ok := column.(Int); ok {
+			pos.startColumn = column.I
+		}
+	}
+	if ok, level := m.Get(MakeKeyword("level")); ok && level.Equals(MakeKeyword("error")) {
+		printParseError(pos, message.(String).S)
+		return
+	}
+	printParseWarning(pos, message.(String).S)
+}
@@ -0,0 +1,307 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependencies between namespaces, as declared by the :require and
+// :use clauses of ns forms read without being evaluated: the graph
+// printed by --deps-graph and the layering rules checked by the linter.
+// The :forbidden-requires map of the .joker config lists the namespaces
+// that a namespace must not require, e.g. {app.db [app.http]}. Both
+// apply to the namespaces under them too (app.db.users must not require
+// app.http.routes either).
+
+var forbiddenRequires map[string][]string
+
+func readForbiddenRequires(configFileName string, obj Object) (map[string][]string, bool) {
+	m, ok := obj.(Map)
+	if !ok {
+		printConfigError(configFileName, ":forbidden-requires value must be a map, got "+obj.GetType().ToString(false))
+		return nil, false
+	}
+	res := make(map[string][]string)
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		from, ok1 := p.Key.(Symbol)
+		to, ok2 := p.Value.(Seqable)
+		if !ok1 || !ok2 {
+			printConfigError(configFileName, ":forbidden-requires must map namespace names to vectors of them, got "+p.Key.ToString(true)+" "+p.Value.ToString(true))
+			return nil, false
+		}
+		for s := to.Seq(); !s.IsEmpty(); s = s.Rest() {
+			sym, ok := s.First().(Symbol)
+			if !ok {
+				printConfigError(configFileName, ":forbidden-requires must map namespace names to vectors of them, got "+s.First().ToString(true))
+				return nil, false
+			}
+			res[from.ToString(false)] = append(res[from.ToString(false)], sym.ToString(false))
+		}
+	}
+	return res, true
+}
+
+func isUnderNamespace(name string, parent string) bool {
+	return name == parent || strings.HasPrefix(name, parent+".")
+}
+
+func requiredNamespace(obj Object, prefix string) (Symbol, bool) {
+	if v, ok := obj.(Vec); ok && v.Count() > 0 {
+		obj = v.At(0)
+	}
+	sym, ok := obj.(Symbol)
+	if !ok || sym.ns != nil {
+		return Symbol{}, false
+	}
+	if prefix != "" {
+		sym = MakeSymbol(prefix + "." + *sym.name).WithInfo(sym.GetInfo()).(Symbol)
+	}
+	return sym, true
+}
+
+// requiredNamespaces returns the symbols naming the namespaces required
+// by seq, an ns form, in order.
+func requiredNamespaces(seq Seq) []Symbol {
+	var res []Symbol
+	for _, clause := range ToSlice(seq.Rest()) {
+		clause, ok := clause.(Seq)
+		if !ok || clause.IsEmpty() || !(clause.First().Equals(KEYWORDS.require) || clause.First().Equals(MakeKeyword("use"))) {
+			continue
+		}
+		for _, libspec := range ToSlice(clause.Rest()) {
+			// Prefix list: (prefix libspec...)
+			if list, ok := libspec.(Seq); ok && !list.IsEmpty() {
+				if prefix, ok := list.First().(Symbol); ok {
+					for _, obj := range ToSlice(list.Rest()) {
+						if sym, ok := requiredNamespace(obj, prefix.ToString(false)); ok {
+							res = append(res, sym)
+						}
+					}
+				}
+				continue
+			}
+			if sym, ok := requiredNamespace(libspec, ""); ok {
+				res = append(res, sym)
+			}
+		}
+	}
+	return res
+}
+
+// checkForbiddenRequires warns on the requires of seq, an ns form, that
+// break the layering rules.
+func checkForbiddenRequires(seq Seq) {
+	if len(forbiddenRequires) == 0 {
+		return
+	}
+	name, ok := Second(seq).(Symbol)
+	if !ok {
+		return
+	}
+	nsName := name.ToString(false)
+	for _, sym := range requiredNamespaces(seq) {
+		required := sym.ToString(false)
+		for from, forbidden := range forbiddenRequires {
+			if !isUnderNamespace(nsName, from) {
+				continue
+			}
+			for _, to := range forbidden {
+				if isUnderNamespace(required, to) {
+					printParseWarning(GetPosition(sym), fmt.Sprintf("%s must not require %s", nsName, required))
+				}
+			}
+		}
+	}
+}
+
+// DepsGraph is the graph of the namespaces defined in a set of files and
+// the namespaces they require.
+type DepsGraph struct {
+	deps map[string][]string
+}
+
+func NewDepsGraph() *DepsGraph {
+	return &DepsGraph{deps: make(map[string][]string)}
+}
+
+// AddFile adds the namespaces defined by the ns forms in filename, whose
+// contents are src, to the graph.
+func (g *DepsGraph) AddFile(filename string, src string) error {
+	deps, err := FileNamespaceDeps(filename, src)
+	if err != nil {
+		return err
+	}
+	for name, required := range deps {
+		all := append(g.deps[name], required...)
+		sort.Strings(all)
+		g.deps[name] = dedupStrings(all)
+	}
+	return nil
+}
+
+// FileNamespaceDeps returns the namespaces defined (by ns forms) in src,
+// the source code in filename, and the namespaces each of them requires.
+func FileNamespaceDeps(filename string, src string) (map[string][]string, error) {
+	forms, err := newSourceText(src).readForms(filename)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]string)
+	for _, form := range forms {
+		seq, ok := form.(Seq)
+		if !ok || seq.IsEmpty() || !isSymbolNamed(seq.First(), "ns") {
+			continue
+		}
+		name, ok := Second(seq).(Symbol)
+		if !ok {
+			continue
+		}
+		deps := res[name.ToString(false)]
+		for _, sym := range requiredNamespaces(seq) {
+			deps = append(deps, sym.ToString(false))
+		}
+		res[name.ToString(false)] = deps
+	}
+	return res, nil
+}
+
+func dedupStrings(sorted []string) []string {
+	res := []string{}
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (g *DepsGraph) namespaces() []string {
+	var res []string
+	for name := range g.deps {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// DOT returns the graph in the DOT language of Graphviz.
+func (g *DepsGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph deps {\n")
+	for _, name := range g.namespaces() {
+		if len(g.deps[name]) == 0 {
+			fmt.Fprintf(&b, "  %q;\n", name)
+		}
+		for _, dep := range g.deps[name] {
+			fmt.Fprintf(&b, "  %q -> %q;\n", name, dep)
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// Map returns the graph as a map from the names of namespaces (as
+// symbols) to vectors of the namespaces they require.
+func (g *DepsGraph) Map() Map {
+	res := EmptyArrayMap()
+	for _, name := range g.namespaces() {
+		deps := EmptyVector()
+		for _, dep := range g.deps[name] {
+			deps = deps.Conjoin(MakeSymbol(dep))
+		}
+		res.Add(MakeSymbol(name), deps)
+	}
+	return res
+}
+
+// Cycles returns the cycles of the graph, one per group of namespaces
+// that (indirectly) require each other, e.g. [a b a], starting with the
+// least name of the group.
+func (g *DepsGraph) Cycles() [][]string {
+	// Tarjan's algorithm for the strongly connected components.
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	var connect func(name string)
+	connect = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, dep := range g.deps[name] {
+			if _, ok := index[dep]; !ok {
+				connect(dep)
+				if lowlink[dep] < lowlink[name] {
+					lowlink[name] = lowlink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowlink[name] {
+				lowlink[name] = index[dep]
+			}
+		}
+		if lowlink[name] == index[name] {
+			var component []string
+			for {
+				n := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[n] = false
+				component = append(component, n)
+				if n == name {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	for _, name := range g.namespaces() {
+		if _, ok := index[name]; !ok {
+			connect(name)
+		}
+	}
+	var res [][]string
+	for _, component := range components {
+		sort.Strings(component)
+		if cycle := g.cycleFrom(component[0], component); cycle != nil {
+			res = append(res, cycle)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i][0] < res[j][0]
+	})
+	return res
+}
+
+// cycleFrom returns the shortest path from start back to itself through
+// the namespaces of component, if any.
+func (g *DepsGraph) cycleFrom(start string, component []string) []string {
+	inComponent := make(map[string]bool)
+	for _, name := range component {
+		inComponent[name] = true
+	}
+	prev := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range g.deps[name] {
+			if dep == start {
+				cycle := []string{start}
+				for n := name; n != start; n = prev[n] {
+					cycle = append(cycle, n)
+				}
+				for i, j := 1, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return append(cycle, start)
+			}
+			if _, seen := prev[dep]; !seen && inComponent[dep] {
+				prev[dep] = name
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil
+}
@@ -274,79 +274,80 @@ type (
 		IsRealized() bool
 	}
 	Types struct {
-		Associative    *Type
-		Callable       *Type
-		Collection     *Type
-		Comparable     *Type
-		Comparator     *Type
-		Counted        *Type
-		CountedIndexed *Type
-		Deref          *Type
-		Channel        *Type
-		Error          *Type
-		Gettable       *Type
-		Indexed        *Type
-		IOReader       *Type
-		IOWriter       *Type
-		KVReduce       *Type
-		Reduce         *Type
-		Map            *Type
-		Meta           *Type
-		Named          *Type
-		Number         *Type
-		Pending        *Type
-		Ref            *Type
-		Reversible     *Type
-		Seq            *Type
-		Seqable        *Type
-		Sequential     *Type
-		Set            *Type
-		Stack          *Type
-		ArrayMap       *Type
-		ArrayMapSeq    *Type
-		ArrayNodeSeq   *Type
-		ArraySeq       *Type
-		MapSet         *Type
-		Atom           *Type
-		BigFloat       *Type
-		BigInt         *Type
-		Boolean        *Type
-		Time           *Type
-		Buffer         *Type
-		Char           *Type
-		ConsSeq        *Type
-		Delay          *Type
-		Double         *Type
-		EvalError      *Type
-		ExInfo         *Type
-		Fn             *Type
-		File           *Type
-		GoObject       *Type
-		BufferedReader *Type
-		HashMap        *Type
-		Int            *Type
-		Keyword        *Type
-		LazySeq        *Type
-		List           *Type
-		MappingSeq     *Type
-		Namespace      *Type
-		Nil            *Type
-		NodeSeq        *Type
-		ParseError     *Type
-		Proc           *Type
-		ProcFn         *Type
-		Ratio          *Type
-		RecurBindings  *Type
-		Regex          *Type
-		String         *Type
-		Symbol         *Type
-		Type           *Type
-		Var            *Type
-		Vector         *Type
-		Vec            *Type
-		ArrayVector    *Type
-		VectorRSeq     *Type
-		VectorSeq      *Type
+		Associative        *Type
+		Callable           *Type
+		Collection         *Type
+		Comparable         *Type
+		Comparator         *Type
+		Counted            *Type
+		CountedIndexed     *Type
+		Deref              *Type
+		Channel            *Type
+		Error              *Type
+		Gettable           *Type
+		Indexed            *Type
+		IOReader           *Type
+		IOWriter           *Type
+		KVReduce           *Type
+		Reduce             *Type
+		Map                *Type
+		Meta               *Type
+		Named              *Type
+		Number             *Type
+		Pending            *Type
+		Ref                *Type
+		Reversible         *Type
+		Seq                *Type
+		Seqable            *Type
+		Sequential         *Type
+		Set                *Type
+		Stack              *Type
+		ArrayMap           *Type
+		ArrayMapSeq        *Type
+		ArrayNodeSeq       *Type
+		ArraySeq           *Type
+		MapSet             *Type
+		Atom               *Type
+		BigFloat           *Type
+		BigInt             *Type
+		Boolean            *Type
+		Time               *Type
+		Buffer             *Type
+		Char               *Type
+		ConsSeq            *Type
+		Delay              *Type
+		Double             *Type
+		EvalError          *Type
+		ExInfo             *Type
+		Fn                 *Type
+		File               *Type
+		GoObject           *Type
+		BufferedReader     *Type
+		HashMap            *Type
+		Int                *Type
+		Keyword            *Type
+		LazySeq            *Type
+		List               *Type
+		MappingSeq         *Type
+		Namespace          *Type
+		Nil                *Type
+		NodeSeq            *Type
+		ParseError         *Type
+		Proc               *Type
+		ProcFn             *Type
+		Ratio              *Type
+		RecurBindings      *Type
+		Regex              *Type
+		StackOverflowError *Type
+		String             *Type
+		Symbol             *Type
+		Type               *Type
+		Var                *Type
+		Vector             *Type
+		Vec                *Type
+		ArrayVector        *Type
+		VectorRSeq         *Type
+		VectorSeq          *Type
 	}
 )
 
@@ -760,12 +761,11 @@ func (fn *Fn) Hash() uint32 {
 func (fn *Fn) Call(args []Object) Object {
 	min := math.MaxInt32
 	max := -1
-	for _, arity := range fn.fnExpr.arities {
+	for i := range fn.fnExpr.arities {
+		arity := &fn.fnExpr.arities[i]
 		a := len(arity.args)
 		if a == len(args) {
-			RT.pushFrame()
-			defer RT.popFrame()
-			return evalLoop(arity.body, fn.env.addFrame(args))
+			return fn.callArity(arity, args)
 		}
 		if min > a {
 			min = a
@@ -799,9 +799,13 @@ func (fn *Fn) Call(args []Object) Object {
 		vargs[i] = args[i]
 	}
 	vargs[len(vargs)-1] = restArgs
+	return fn.callArity(v, vargs)
+}
+
+func (fn *Fn) callArity(arity *FnArityExpr, args []Object) Object {
 	RT.pushFrame()
 	defer RT.popFrame()
-	return evalLoop(v.body, fn.env.addFrame(vargs))
+	return arity.evalBody(fn.env.addFrame(args))
 }
 
 func compare(c Callable, a, b Object) int {
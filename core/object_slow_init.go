@@ -78,14 +78,16 @@ func init() {
 		Ratio:         RegRefType("Ratio", (*Ratio)(nil), "Wraps the Go 'math.big/Rat' type"),
 		RecurBindings: RegRefType("RecurBindings", (*RecurBindings)(nil), ""),
 		Regex:         RegRefType("Regex", (*Regex)(nil), "Wraps the Go 'regexp.Regexp' type"),
-		String:        RegType("String", (*String)(nil), "Wraps the Go 'string' type"),
-		Symbol:        RegType("Symbol", (*Symbol)(nil), ""),
-		Type:          RegRefType("Type", (*Type)(nil), ""),
-		Var:           RegRefType("Var", (*Var)(nil), ""),
-		Vector:        RegRefType("Vector", (*Vector)(nil), ""),
-		Vec:           RegInterface("Vec", (*Vec)(nil), ""),
-		ArrayVector:   RegRefType("ArrayVector", (*ArrayVector)(nil), ""),
-		VectorRSeq:    RegRefType("VectorRSeq", (*VectorRSeq)(nil), ""),
-		VectorSeq:     RegRefType("VectorSeq", (*VectorSeq)(nil), ""),
+		StackOverflowError: RegRefType("StackOverflowError", (*StackOverflowError)(nil),
+			"Raised when calls of Joker functions are nested too deeply"),
+		String:      RegType("String", (*String)(nil), "Wraps the Go 'string' type"),
+		Symbol:      RegType("Symbol", (*Symbol)(nil), ""),
+		Type:        RegRefType("Type", (*Type)(nil), ""),
+		Var:         RegRefType("Var", (*Var)(nil), ""),
+		Vector:      RegRefType("Vector", (*Vector)(nil), ""),
+		Vec:         RegInterface("Vec", (*Vec)(nil), ""),
+		ArrayVector: RegRefType("ArrayVector", (*ArrayVector)(nil), ""),
+		VectorRSeq:  RegRefType("VectorRSeq", (*VectorRSeq)(nil), ""),
+		VectorSeq:   RegRefType("VectorSeq", (*VectorSeq)(nil), ""),
 	}
 }
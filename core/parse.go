@@ -85,9 +85,13 @@ type (
 	}
 	FnArityExpr struct {
 		Position
-		args       []Symbol
-		body       []Expr
-		taggedType *Type
+		args          []Symbol
+		body          []Expr
+		taggedType    *Type
+		compiled      compiledExpr
+		compiledFrame int
+		wrapped       *CallExpr
+		wrapChecked   bool
 	}
 	FnExpr struct {
 		Position
@@ -147,6 +151,8 @@ type (
 		frame        int
 		isUsed       bool
 		inferredType *Type
+		// The expression the local is bound to by let, if any.
+		value Expr
 	}
 	Bindings struct {
 		bindings map[*string]*Binding
@@ -167,12 +173,26 @@ type (
 		noRecurAllowed         bool
 		isUnknownCallableScope bool
 		isSetNow               bool
+		// The var whose value is being parsed, outside of fn bodies.
+		defVar *Var
 	}
+	// Duplicate keys in map and set literals and duplicate case
+	// constants have no toggles: they fail to read or expand in
+	// Clojure too, so they are always errors.
 	Warnings struct {
 		ifWithoutElse           bool
 		unusedFnParameters      bool
 		fnWithEmptyBody         bool
+		shadowedVar             bool
+		shadowedLocal           bool
+		condWithoutElse         bool
+		misplacedDocstring      bool
+		missingDocstring        bool
+		selfReferentialDef      bool
+		unreachableCode         bool
+		duplicateRequire        bool
 		ignoredUnusedNamespaces Set
+		allowedDeprecated       Set
 		IgnoredFileRegexes      []*regexp.Regexp
 		entryPoints             Set
 	}
@@ -198,6 +218,17 @@ type (
 		ifWithoutElse      Keyword
 		unusedFnParameters Keyword
 		fnWithEmptyBody    Keyword
+		shadowedVar        Keyword
+		shadowedLocal      Keyword
+		condWithoutElse    Keyword
+		misplacedDocstring Keyword
+		missingDocstring   Keyword
+		selfReferentialDef Keyword
+		unreachableCode    Keyword
+		duplicateRequire   Keyword
+		deprecated         Keyword
+		supersededBy       Keyword
+		noDoc              Keyword
 		_prefix            Keyword
 		pos                Keyword
 		startLine          Keyword
@@ -225,6 +256,8 @@ type (
 		ascii              Keyword
 		unicode            Keyword
 		any                Keyword
+		format             Keyword
+		styleIndent        Keyword
 	}
 	Symbols struct {
 		joker_core         Symbol
@@ -273,6 +306,7 @@ type (
 		proxy              Symbol
 		reify              Symbol
 		dot                Symbol
+		defmacro           Symbol
 	}
 	Str struct {
 		_if          *string
@@ -304,8 +338,12 @@ var (
 	CREATE_NS_VAR  *Var
 	IN_NS_VAR      *Var
 	WARNINGS       = Warnings{
-		fnWithEmptyBody: true,
-		entryPoints:     EmptySet(),
+		fnWithEmptyBody:    true,
+		misplacedDocstring: true,
+		unreachableCode:    true,
+		duplicateRequire:   true,
+		entryPoints:        EmptySet(),
+		allowedDeprecated:  EmptySet(),
 	}
 )
 
@@ -387,18 +425,50 @@ func (b *Bindings) PopFrame() *Bindings {
 	return b.parent
 }
 
-func (b *Bindings) AddBinding(sym Symbol, index int, skipUnused bool, inferredType *Type) {
+func isWrittenByUser(obj Object) bool {
+	filename := GetPosition(obj).filename
+	return filename != nil && filename != STR.coreFilename
+}
+
+func (b *Bindings) warnOnShadowing(sym Symbol) {
+	if strings.HasPrefix(*sym.name, "_") || !isWrittenByUser(sym) {
+		return
+	}
+	if old := b.GetBinding(sym); old != nil {
+		// Macros like as-> bind the same symbol repeatedly.
+		if WARNINGS.shadowedLocal && GetPosition(old.name) != GetPosition(sym) {
+			printParseWarning(GetPosition(sym), "shadowed local: "+sym.ToString(false))
+		}
+		return
+	}
+	if WARNINGS.shadowedVar {
+		if vr, ok := GLOBAL_ENV.Resolve(sym); ok && !vr.isFake {
+			printParseWarning(GetPosition(sym), "shadowed var: "+vr.Name())
+		}
+	}
+}
+
+func (b *Bindings) AddBinding(sym Symbol, index int, skipUnused bool, value Expr) {
+	if LINTER_MODE {
+		b.warnOnShadowing(sym)
+	}
 	if LINTER_MODE && !skipUnused {
 		old := b.bindings[sym.name]
 		if old != nil && needsUnusedWarning(old) {
 			printParseWarning(GetPosition(old.name), "Unused binding: "+old.name.ToString(false))
+			addLintFix(FIX_UNUSED_BINDING, GetPosition(old.name), old.name.ToString(false))
 		}
 	}
+	var inferredType *Type
+	if LINTER_MODE && value != nil {
+		inferredType = value.InferType()
+	}
 	b.bindings[sym.name] = &Binding{
 		name:         sym,
 		frame:        b.frame,
 		index:        index,
 		inferredType: inferredType,
+		value:        value,
 	}
 }
 
@@ -534,6 +604,7 @@ func WarnOnUnusedNamespaces() {
 	sort.Strings(names)
 	for _, name := range names {
 		printParseWarning(positions[name], "unused namespace "+name)
+		addLintFix(FIX_UNUSED_NAMESPACE, positions[name], name)
 	}
 }
 
@@ -734,12 +805,25 @@ func updateVar(vr *Var, info *ObjectInfo, valueExpr Expr, sym Symbol) {
 		}
 		vr.taggedType = getTaggedType(sym)
 	}
+	if LINTER_MODE {
+		recordDeprecatedVar(vr, meta)
+	}
 }
 
 func isCreatedByMacro(formSeq Seq) bool {
 	return formSeq.First().GetInfo().Pos().filename == STR.coreFilename
 }
 
+// isFormWrittenByUser is like isWrittenByUser, but checks lists by
+// their first element, since a list created by a macro gets the
+// position of the macro call.
+func isFormWrittenByUser(obj Object) bool {
+	if seq, ok := obj.(Seq); ok && !seq.IsEmpty() {
+		obj = seq.First()
+	}
+	return isWrittenByUser(obj)
+}
+
 func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 	count := checkForm(obj, 2, 4)
 	seq := obj.(Seq)
@@ -755,7 +839,12 @@ func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 		}
 		symWithoutNs := sym
 		symWithoutNs.ns = nil
+		// Redefining a var (or one referred from another namespace) in
+		// terms of its previous value is fine.
+		prev, isBound := ctx.GlobalEnv.Resolve(symWithoutNs)
+		isBound = isBound && (prev.Value != nil || prev.expr != nil)
 		vr := ctx.GlobalEnv.CurrentNamespace().Intern(symWithoutNs)
+		trackReference(vr, sym, "definition")
 		if isForLinter {
 			vr.isGloballyUsed = true
 		}
@@ -767,6 +856,12 @@ func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 			isCreatedByMacro: isCreatedByMacro(seq),
 		}
 		meta = sym.GetMeta()
+		defVar := ctx.defVar
+		ctx.defVar = vr
+		if isBound || (count > 2 && isNamedFnForm(seq.Rest().Rest(), symWithoutNs)) {
+			ctx.defVar = nil
+		}
+		defer func() { ctx.defVar = defVar }()
 		if count == 3 {
 			res.value = Parse(Third(seq), ctx)
 		} else if count == 4 {
@@ -784,6 +879,18 @@ func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 			}
 		}
 		updateVar(vr, obj.GetInfo(), res.value, sym)
+		if apiDocs != nil && !isForLinter && isWrittenByUser(sym) {
+			apiDocs.recordVar(vr, meta, GetPosition(sym))
+		}
+		if LINTER_MODE && WARNINGS.missingDocstring && count > 2 && !vr.isPrivate && !isForLinter && isWrittenByUser(sym) {
+			hasDoc := false
+			if meta != nil {
+				hasDoc, _ = meta.Get(KEYWORDS.doc)
+			}
+			if !hasDoc {
+				printParseWarning(GetPosition(sym), "missing docstring: "+sym.ToString(false))
+			}
+		}
 		if meta != nil {
 			res.meta = Parse(DeriveReadObject(obj, meta), ctx)
 		}
@@ -793,6 +900,26 @@ func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 	}
 }
 
+// isNamedFnForm reports whether the value form of a def (the last of
+// forms) is (fn name ...), whose references to name are to the fn
+// itself even where fn cannot be resolved.
+func isNamedFnForm(forms Seq, name Symbol) bool {
+	var value Object
+	for ; !forms.IsEmpty(); forms = forms.Rest() {
+		value = forms.First()
+	}
+	seq, ok := value.(Seq)
+	if !ok || seq.IsEmpty() {
+		return false
+	}
+	head, ok := seq.First().(Symbol)
+	if !ok || (head.Name() != "fn" && head.Name() != "fn*") {
+		return false
+	}
+	self, ok := Second(seq).(Symbol)
+	return ok && self.Equals(name)
+}
+
 func skipRedundantDo(obj Object) bool {
 	if meta, ok := obj.(Meta); ok {
 		if m := meta.GetMeta(); m != nil {
@@ -809,6 +936,7 @@ func parseBody(seq Seq, ctx *ParseContext) []Expr {
 	ctx.recur = false
 	defer func() { ctx.recur = recur }()
 	res := make([]Expr, 0)
+	isUnreachable := false
 	for !seq.IsEmpty() {
 		ro := seq.First()
 		expr := Parse(ro, ctx)
@@ -818,10 +946,22 @@ func parseBody(seq Seq, ctx *ParseContext) []Expr {
 		}
 		res = append(res, expr)
 		if LINTER_MODE {
+			// Forms following throw in a macro expansion, such as (recur) in
+			// (while c (throw ...)), are not the user's mistake.
+			if WARNINGS.unreachableCode && !isUnreachable && !seq.IsEmpty() && isFormWrittenByUser(seq.First()) {
+				switch expr.(type) {
+				case *ThrowExpr, *RecurExpr:
+					// Only the first unreachable form is reported.
+					isUnreachable = true
+					printParseWarning(GetPosition(seq.First()), "unreachable code")
+				}
+			}
 			if defExpr, ok := expr.(*DefExpr); ok && !defExpr.isCreatedByMacro {
 				printParseWarning(defExpr.Pos(), "inline def")
+				addLintFix(FIX_INLINE_DEF, defExpr.Pos(), "")
 			} else if doExpr, ok := expr.(*DoExpr); ok && !doExpr.isCreatedByMacro && !skipRedundantDo(ro) {
 				printParseWarning(doExpr.Pos(), "redundant do form")
+				addLintFix(FIX_REDUNDANT_DO, doExpr.Pos(), "")
 			}
 		}
 	}
@@ -932,6 +1072,7 @@ func addArity(fn *FnExpr, sig Seq, ctx *ParseContext) {
 			sort.Sort(BySymbolName(unused))
 			for _, u := range unused {
 				printParseWarning(GetPosition(u), "unused parameter: "+u.ToString(false))
+				addLintFix(FIX_UNUSED_BINDING, GetPosition(u), u.ToString(false))
 			}
 		}
 	}
@@ -957,6 +1098,10 @@ func wrapWithMeta(fnExpr *FnExpr, obj Object, ctx *ParseContext) Expr {
 //	([a & b] a b))
 func parseFn(obj Object, ctx *ParseContext) Expr {
 	res := &FnExpr{Position: GetPosition(obj)}
+	// The var is bound by the time the fn is called.
+	defVar := ctx.defVar
+	ctx.defVar = nil
+	defer func() { ctx.defVar = defVar }()
 	bodies := obj.(Seq).Rest()
 	p := bodies.First()
 	if IsSymbol(p) { // self reference
@@ -1204,14 +1349,10 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 					panic(&ParseError{obj: s, msg: "Unsupported binding form: " + sym.ToString(false)})
 				}
 			}
-			var inferredType *Type
 			if formName != "letfn" {
 				res.values[i] = Parse(b.At(i*2+1), ctx)
-				if LINTER_MODE {
-					inferredType = res.values[i].InferType()
-				}
 			}
-			ctx.localBindings.AddBinding(res.names[i], i, skipUnused, inferredType)
+			ctx.localBindings.AddBinding(res.names[i], i, skipUnused, res.values[i])
 		}
 
 		if formName == "letfn" {
@@ -1247,6 +1388,7 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 				sort.Sort(BySymbolName(unused))
 				for _, u := range unused {
 					printParseWarning(GetPosition(u), "unused binding: "+u.ToString(false))
+					addLintFix(FIX_UNUSED_BINDING, GetPosition(u), u.ToString(false))
 				}
 			}
 		}
@@ -1293,6 +1435,9 @@ func resolveMacro(obj Object, ctx *ParseContext) (vr *Var, name string) {
 		if !ok || !vr.isMacro || vr.Value == nil {
 			return nil, name
 		}
+		ctx.GlobalEnv.checkSandbox(vr, obj)
+		trackReference(vr, obj, "reference")
+		warnIfDeprecated(vr, obj)
 		vr.isUsed = true
 		vr.isGloballyUsed = true
 		if vr.ns == nil {
@@ -1411,9 +1556,17 @@ func expandMemberRef(seq Seq, ctx *ParseContext) Object {
 }
 
 func macroexpand1(seq Seq, ctx *ParseContext) Object {
+	if LINTER_MODE {
+		if res := expandForLinter(seq, ctx); res != nil {
+			return res
+		}
+	}
 	op := seq.First()
 	vr, name := resolveMacro(op, ctx)
 	if vr != nil {
+		if LINTER_MODE && vr.ns == ctx.GlobalEnv.CoreNamespace && isWrittenByUser(seq.First()) {
+			checkCoreMacroCall(*vr.name.name, seq)
+		}
 		expr := &MacroCallExpr{
 			Position: GetPosition(seq),
 			macro:    vr.Value.(Callable),
@@ -1435,6 +1588,66 @@ func macroexpand1(seq Seq, ctx *ParseContext) Object {
 	return seq
 }
 
+// checkCoreMacroCall checks the call of the joker.core macro named name,
+// as written, before it's expanded.
+func checkCoreMacroCall(name string, seq Seq) {
+	switch name {
+	case "ns":
+		recordDeprecatedNamespace(seq)
+		if apiDocs != nil {
+			apiDocs.recordNamespace(seq)
+		}
+		checkForbiddenRequires(seq)
+	case "cond":
+		if WARNINGS.condWithoutElse {
+			checkCondElse(seq)
+		}
+	case "defn", "defn-", "defmacro":
+		if WARNINGS.misplacedDocstring {
+			checkDocstringPlacement(seq)
+		}
+	}
+}
+
+func checkCondElse(seq Seq) {
+	clauses := ToSlice(seq.Rest())
+	if len(clauses) == 0 || len(clauses)%2 != 0 {
+		return
+	}
+	if !clauses[len(clauses)-2].Equals(KEYWORDS.else_) {
+		printParseWarning(GetPosition(seq), "cond without :else as last test")
+	}
+}
+
+func checkArityDocstring(sig Seq) {
+	body := sig.Rest()
+	if _, ok := body.First().(String); ok && !body.Rest().IsEmpty() {
+		printParseWarning(GetPosition(body.First()), "misplaced docstring")
+	}
+}
+
+// Examples:
+// (defn f [x] "doc" x)
+// (defn f ([x] "doc" x))
+func checkDocstringPlacement(seq Seq) {
+	fdecl := seq.Rest().Rest()
+	if _, ok := fdecl.First().(String); ok {
+		fdecl = fdecl.Rest()
+	}
+	if _, ok := fdecl.First().(Map); ok {
+		fdecl = fdecl.Rest()
+	}
+	if IsVector(fdecl.First()) {
+		checkArityDocstring(fdecl)
+		return
+	}
+	for ; !fdecl.IsEmpty(); fdecl = fdecl.Rest() {
+		if sig, ok := fdecl.First().(Seq); ok && !sig.IsEmpty() && IsVector(sig.First()) {
+			checkArityDocstring(sig)
+		}
+	}
+}
+
 func reportNotAFunction(pos Position, name string) {
 	printParseWarning(pos, name+" is not a function")
 }
@@ -1442,9 +1655,13 @@ func reportNotAFunction(pos Position, name string) {
 func getTaggedType(obj Meta) *Type {
 	if m := obj.GetMeta(); m != nil {
 		if ok, typeName := m.Get(KEYWORDS.tag); ok {
-			if typeSym, ok := typeName.(Symbol); ok {
-				if t := TYPES[typeSym.name]; t != nil {
-					return t
+			switch typeDecl := typeName.(type) {
+			case Symbol:
+				return TYPES[typeDecl.name]
+			case String:
+				// gostd wrappers are tagged with strings, e.g. ^"Int".
+				if !strings.Contains(typeDecl.S, "|") {
+					return TYPES[MakeSymbol(typeDecl.S).name]
 				}
 			}
 		}
@@ -1501,7 +1718,7 @@ func checkTypes(declaredArgs []Symbol, call *CallExpr) bool {
 			passedType := call.args[i].InferType()
 			if passedType != nil {
 				if !isTypeOneOf(declaredTypes, passedType) {
-					printParseWarning(call.args[i].Pos(), fmt.Sprintf("arg[%d] of %s must have type %s, got %s", i, call.Name(), typesString(declaredTypes), passedType.ToString(false)))
+					printParseWarning(call.args[i].Pos(), fmt.Sprintf("arg[%d] of %s must have type %s, got %s%s", i, call.Name(), typesString(declaredTypes), passedType.ToString(false), inferenceChainString(call.args[i])))
 					res = true
 				}
 			}
@@ -1767,6 +1984,9 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 					}
 					vr = InternFakeSymbol(symNs, sym)
 				}
+				ctx.GlobalEnv.checkSandbox(vr, obj)
+				trackReference(vr, sym, "reference")
+				warnIfDeprecated(vr, sym)
 				vr.isUsed = true
 				vr.isGloballyUsed = true
 				vr.ns.isUsed = true
@@ -1797,6 +2017,8 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 					}
 					vr = InternFakeSymbol(symNs, sym)
 				}
+				ctx.GlobalEnv.checkSandbox(vr, obj)
+				trackReference(vr, sym, "reference")
 				vr.isUsed = true
 				vr.isGloballyUsed = true
 				vr.ns.isUsed = true
@@ -1959,6 +2181,8 @@ func isJavaSymbol(sym Symbol) bool {
 }
 
 func MakeVarRefExpr(vr *Var, obj Object) *VarRefExpr {
+	trackReference(vr, obj, "reference")
+	warnIfDeprecated(vr, obj)
 	vr.isUsed = true
 	vr.isGloballyUsed = true
 	vr.ns.isUsed = true
@@ -1980,6 +2204,10 @@ func parseSymbol(obj Object, ctx *ParseContext) Expr {
 		}
 	}
 	if vr, ok := ctx.GlobalEnv.Resolve(sym); ok {
+		ctx.GlobalEnv.checkSandbox(vr, obj)
+		if LINTER_MODE && WARNINGS.selfReferentialDef && vr == ctx.defVar {
+			printParseWarning(GetPosition(obj), "self-referential def: "+sym.ToString(false))
+		}
 		return MakeVarRefExpr(vr, obj)
 	}
 	if sym.ns == nil && TYPES[sym.name] != nil {
@@ -2071,7 +2299,7 @@ func TryParse(obj Object, ctx *ParseContext) (expr Expr, err error) {
 			switch r.(type) {
 			case *ParseError:
 				err = r.(error)
-			case *EvalError:
+			case *EvalError, *StackOverflowError:
 				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
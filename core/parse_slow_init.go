@@ -27,6 +27,17 @@ var (
 		ifWithoutElse:      MakeKeyword("if-without-else"),
 		unusedFnParameters: MakeKeyword("unused-fn-parameters"),
 		fnWithEmptyBody:    MakeKeyword("fn-with-empty-body"),
+		shadowedVar:        MakeKeyword("shadowed-var"),
+		shadowedLocal:      MakeKeyword("shadowed-local"),
+		condWithoutElse:    MakeKeyword("cond-without-else"),
+		misplacedDocstring: MakeKeyword("misplaced-docstring"),
+		missingDocstring:   MakeKeyword("missing-docstring"),
+		selfReferentialDef: MakeKeyword("self-referential-def"),
+		unreachableCode:    MakeKeyword("unreachable-code"),
+		duplicateRequire:   MakeKeyword("duplicate-require"),
+		deprecated:         MakeKeyword("deprecated"),
+		supersededBy:       MakeKeyword("superseded-by"),
+		noDoc:              MakeKeyword("no-doc"),
 		_prefix:            MakeKeyword("_prefix"),
 		pos:                MakeKeyword("pos"),
 		startLine:          MakeKeyword("start-line"),
@@ -54,6 +65,8 @@ var (
 		ascii:              MakeKeyword("ascii"),
 		unicode:            MakeKeyword("unicode"),
 		any:                MakeKeyword("any"),
+		format:             MakeKeyword("format"),
+		styleIndent:        MakeKeyword("style/indent"),
 	}
 	SYMBOLS = Symbols{
 		joker_core:         MakeSymbol("joker.core"),
@@ -102,6 +115,7 @@ var (
 		proxy:              MakeSymbol("proxy"),
 		reify:              MakeSymbol("reify"),
 		dot:                MakeSymbol("."),
+		defmacro:           MakeSymbol("defmacro"),
 	}
 	STR = Str{
 		_if:          STRINGS.Intern("if"),
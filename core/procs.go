@@ -591,7 +591,7 @@ var procIntern = func(args []Object) Object {
 	sym := EnsureArgIsSymbol(args, 1)
 	vr := ns.Intern(sym)
 	if len(args) == 3 {
-		vr.Value = args[2]
+		vr.SetValue(args[2])
 	}
 	return vr
 }
@@ -650,14 +650,25 @@ var procResetVals = func(args []Object) Object {
 	return NewVectorFrom(oldValue, a.value)
 }
 
+func checkMetaChange(r Ref) {
+	switch r := r.(type) {
+	case *Var:
+		GLOBAL_ENV.checkBuiltinNamespaceChange(r.ns, "Changing metadata of a var in namespace")
+	case *Namespace:
+		GLOBAL_ENV.checkBuiltinNamespaceChange(r, "Changing metadata of namespace")
+	}
+}
+
 var procAlterMeta = func(args []Object) Object {
 	r := EnsureArgIsRef(args, 0)
+	checkMetaChange(r)
 	f := EnsureArgIsFn(args, 1)
 	return r.AlterMeta(f, args[2:])
 }
 
 var procResetMeta = func(args []Object) Object {
 	r := EnsureArgIsRef(args, 0)
+	checkMetaChange(r)
 	m := EnsureArgIsMap(args, 1)
 	return r.ResetMeta(m)
 }
@@ -1336,7 +1347,7 @@ var procSort = func(args []Object) Object {
 var procEval = func(args []Object) Object {
 	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
 	expr := Parse(args[0], parseContext)
-	return Eval(expr, nil)
+	return EvalTopLevel(expr)
 }
 
 var procType = func(args []Object) Object {
@@ -1608,7 +1619,12 @@ var procVarGet = func(args []Object) Object {
 }
 
 var procVarSet = func(args []Object) Object {
-	EnsureArgIsVar(args, 0).Value = args[1]
+	vr := EnsureArgIsVar(args, 0)
+	if ns, ok := args[1].(*Namespace); ok && vr == GLOBAL_ENV.ns {
+		// Code in a built-in namespace would def and refer vars in it.
+		GLOBAL_ENV.checkBuiltinNamespaceChange(ns, "Switching to namespace")
+	}
+	vr.SetValue(args[1])
 	return args[1]
 }
 
@@ -1873,11 +1889,11 @@ var procInternFakeVar = func(args []Object) Object {
 
 var procParse = func(args []Object) Object {
 	lm, _ := GLOBAL_ENV.Resolve(MakeSymbol("joker.core/*linter-mode*"))
-	lm.Value = Boolean{B: true}
+	lm.SetValue(Boolean{B: true})
 	LINTER_MODE = true
 	defer func() {
 		LINTER_MODE = false
-		lm.Value = Boolean{B: false}
+		lm.SetValue(Boolean{B: false})
 	}()
 	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
 	res := Parse(args[0], parseContext)
@@ -1906,7 +1922,7 @@ var procCloseChan = func(args []Object) Object {
 	return NIL
 }
 
-var procSend = func(args []Object) (obj Object) {
+var procSend = func(args []Object) Object {
 	CheckArity(args, 2, 2)
 	ch := EnsureArgIsChannel(args, 0)
 	v := args[1]
@@ -1916,25 +1932,33 @@ var procSend = func(args []Object) (obj Object) {
 	if ch.isClosed {
 		return MakeBoolean(false)
 	}
-	obj = MakeBoolean(true)
-	defer func() {
-		if r := recover(); r != nil {
-			RT.GIL.Lock()
-			obj = MakeBoolean(false)
-		}
-	}()
-	RT.GIL.Unlock()
-	ch.ch <- MakeFutureResult(v, nil)
-	RT.GIL.Lock()
-	return
+	interrupt, timeout := Interrupted(), TimedOut()
+	in := ReleaseGIL()
+	sent, interrupted := ch.send(MakeFutureResult(v, nil), interrupt, timeout)
+	in.Enter()
+	if interrupted {
+		AbortWait()
+	}
+	return MakeBoolean(sent)
 }
 
 var procReceive = func(args []Object) Object {
 	CheckArity(args, 1, 1)
 	ch := EnsureArgIsChannel(args, 0)
-	RT.GIL.Unlock()
-	res, ok := <-ch.ch
-	RT.GIL.Lock()
+	interrupt, timeout := Interrupted(), TimedOut()
+	in := ReleaseGIL()
+	var res FutureResult
+	var ok bool
+	select {
+	case res, ok = <-ch.ch:
+		in.Enter()
+	case <-interrupt:
+		in.Enter()
+		AbortWait()
+	case <-timeout:
+		in.Enter()
+		AbortWait()
+	}
 	if !ok {
 		return NIL
 	}
@@ -1948,6 +1972,7 @@ var procGo = func(args []Object) Object {
 	CheckArity(args, 1, 1)
 	f := EnsureArgIsCallable(args, 0)
 	ch := MakeChannel(make(chan FutureResult, 1))
+	in := ActiveInterpreter()
 	go func() {
 
 		defer func() {
@@ -1957,14 +1982,14 @@ var procGo = func(args []Object) Object {
 					ch.ch <- MakeFutureResult(NIL, r)
 					ch.Close()
 				default:
-					RT.GIL.Unlock()
+					in.Leave()
 					panic(r)
 				}
 			}
-			RT.GIL.Unlock()
+			in.Leave()
 		}()
 
-		RT.GIL.Lock()
+		in.Enter()
 		res := f.Call([]Object{})
 		ch.ch <- MakeFutureResult(res, nil)
 		ch.Close()
@@ -2025,7 +2050,28 @@ var procIncProblemCount = func(args []Object) Object {
 	return NIL
 }
 
+// procWarnOnDuplicateRequire reports requiring lib, which has already
+// been loaded, unless the duplicate-require warning is turned off.
+var procWarnOnDuplicateRequire = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	if WARNINGS.duplicateRequire {
+		printParseWarning(GetPosition(args[0]), "duplicate require for "+args[0].ToString(false))
+	}
+	return NIL
+}
+
 func ProcessReader(reader *Reader, filename string, phase Phase) error {
+	_, err := processReader(reader, filename, phase)
+	return err
+}
+
+// EvalReader evaluates the forms read from reader, printing nothing,
+// and returns the value of the last one (nil if there are none).
+func EvalReader(reader *Reader, filename string) (Object, error) {
+	return processReader(reader, filename, EVAL)
+}
+
+func processReader(reader *Reader, filename string, phase Phase) (Object, error) {
 	if phase == FORMAT {
 		FORMAT_MODE = true
 		HASHMAP_THRESHOLD = 100000
@@ -2041,17 +2087,18 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 		parseContext.GlobalEnv.SetFilename(MakeString(s))
 	}
 	var prevObj Object
+	var res Object = NIL
 	for {
 		obj, err := TryRead(reader)
 		if err == io.EOF {
 			if FORMAT_MODE && prevObj != nil {
 				fmt.Fprint(Stdout, "\n")
 			}
-			return nil
+			return res, nil
 		}
 		if err != nil {
 			fmt.Fprintln(Stderr, err)
-			return err
+			return nil, err
 		}
 		if phase == READ {
 			continue
@@ -2073,18 +2120,21 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 		expr, err := TryParse(obj, parseContext)
 		if err != nil {
 			fmt.Fprintln(Stderr, err)
+		} else if LINTER_MODE {
+			runLintRules(expr)
 		}
 		if phase == PARSE {
 			continue
 		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 		obj, err = TryEval(expr)
 		if err != nil {
 			fmt.Fprintln(Stderr, err)
-			return err
+			return nil, err
 		}
+		res = obj
 		if phase == EVAL {
 			continue
 		}
@@ -2236,6 +2286,10 @@ func knownMacrosToMap(km Object) (Map, error) {
 func ReadConfig(filename string, workingDir string) {
 	LINTER_CONFIG = GLOBAL_ENV.CoreNamespace.Intern(MakeSymbol("*linter-config*"))
 	LINTER_CONFIG.Value = EmptyArrayMap()
+	lintRuleNamespaces = nil
+	lintAs = nil
+	lintHooks = nil
+	forbiddenRequires = nil
 	configFileName := findConfigFile(filename, workingDir, false)
 	if configFileName == "" {
 		return
@@ -2295,6 +2349,16 @@ func ReadConfig(filename string, workingDir string) {
 			return
 		}
 	}
+	ok, allowedDeprecated := configMap.Get(MakeKeyword("allowed-deprecated"))
+	if ok {
+		seq, ok1 := allowedDeprecated.(Seqable)
+		if ok1 {
+			WARNINGS.allowedDeprecated = NewSetFromSeq(seq.Seq())
+		} else {
+			printConfigError(configFileName, ":allowed-deprecated value must be a vector, got "+allowedDeprecated.GetType().ToString(false))
+			return
+		}
+	}
 	ok, knownNamespaces := configMap.Get(MakeKeyword("known-namespaces"))
 	if ok {
 		if _, ok1 := knownNamespaces.(Seqable); !ok1 {
@@ -2339,6 +2403,62 @@ func ReadConfig(filename string, workingDir string) {
 		if ok, v := m.Get(KEYWORDS.fnWithEmptyBody); ok {
 			WARNINGS.fnWithEmptyBody = ToBool(v)
 		}
+		if ok, v := m.Get(KEYWORDS.shadowedVar); ok {
+			WARNINGS.shadowedVar = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.shadowedLocal); ok {
+			WARNINGS.shadowedLocal = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.condWithoutElse); ok {
+			WARNINGS.condWithoutElse = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.misplacedDocstring); ok {
+			WARNINGS.misplacedDocstring = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.missingDocstring); ok {
+			WARNINGS.missingDocstring = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.selfReferentialDef); ok {
+			WARNINGS.selfReferentialDef = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.unreachableCode); ok {
+			WARNINGS.unreachableCode = ToBool(v)
+		}
+		if ok, v := m.Get(KEYWORDS.duplicateRequire); ok {
+			WARNINGS.duplicateRequire = ToBool(v)
+		}
+	}
+	if ok, rules := configMap.Get(MakeKeyword("lint-rules")); ok {
+		if !readLintRulesConfig(configFileName, rules) {
+			return
+		}
+	}
+	if ok, v := configMap.Get(MakeKeyword("lint-as")); ok {
+		if lintAs, ok = readLintSymbolMap(configFileName, ":lint-as", v); !ok {
+			return
+		}
+	}
+	if ok, v := configMap.Get(MakeKeyword("lint-hooks")); ok {
+		if lintHooks, ok = readLintSymbolMap(configFileName, ":lint-hooks", v); !ok {
+			return
+		}
+		lintRulesDir = filepath.Dir(configFileName)
+	}
+	if ok, v := configMap.Get(MakeKeyword("forbidden-requires")); ok {
+		if forbiddenRequires, ok = readForbiddenRequires(configFileName, v); !ok {
+			return
+		}
+	}
+	if ok, format := configMap.Get(KEYWORDS.format); ok {
+		m, ok := format.(Map)
+		if !ok {
+			printConfigError(configFileName, ":format value must be a map, got "+format.GetType().ToString(false))
+			return
+		}
+		if err := parseFormatConfig(m); err != nil {
+			printConfigError(configFileName, err.Error())
+			return
+		}
 	}
 	if ok, valid := configMap.Get(KEYWORDS.validIdent); ok {
 		m, ok := valid.(Map)
@@ -2417,6 +2537,7 @@ func ProcessLinterFiles(dialect Dialect, filename string, workingDir string) {
 	if dialect == EDN {
 		return
 	}
+	LoadLintRules()
 	configDir := findConfigFile(filename, workingDir, true)
 	if configDir == "" {
 		return
@@ -178,6 +178,7 @@ func init() {
 	intern("intern-fake-var__", procInternFakeVar, "procInternFakeVar")
 	intern("parse__", procParse, "procParse")
 	intern("inc-problem-count__", procIncProblemCount, "procIncProblemCount")
+	intern("warn-on-duplicate-require__", procWarnOnDuplicateRequire, "procWarnOnDuplicateRequire")
 	intern("types__", procTypes, "procTypes")
 	intern("go__", procGo, "procGo")
 	intern("<!__", procReceive, "procReceive")
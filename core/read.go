@@ -1502,7 +1502,7 @@ func TryRead(reader *Reader) (obj Object, err error) {
 				err = r.(error)
 			case *ParseError:
 				err = r.(error)
-			case *EvalError:
+			case *EvalError, *StackOverflowError:
 				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
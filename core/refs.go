@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Finding and renaming the references to a var across a project
+// (--refs and --rename). While linting, the parser records the
+// definitions of and references to the target var, resolved as they
+// are for the linter's other checks (through aliases, :refer, etc.).
+// Symbols in :refer lists are not parsed, so they are found in the ns
+// forms, read in format mode.
+
+type reference struct {
+	pos  Position
+	sym  Symbol
+	kind string
+}
+
+var (
+	refsTarget *Symbol
+	references []reference
+)
+
+// SetReferencesTarget makes the parser record the references to the var
+// named by sym (a namespace-qualified symbol).
+func SetReferencesTarget(sym Symbol) {
+	refsTarget = &sym
+	references = nil
+}
+
+func isReferencesTarget(vr *Var) bool {
+	return vr != nil && vr.ns != nil &&
+		*vr.ns.Name.name == *refsTarget.ns && *vr.name.name == *refsTarget.name
+}
+
+func trackReference(vr *Var, obj Object, kind string) {
+	if refsTarget == nil || !isReferencesTarget(vr) {
+		return
+	}
+	sym, ok := obj.(Symbol)
+	if !ok {
+		return
+	}
+	if pos := GetPosition(sym); pos.filename != nil && pos.startLine != 0 {
+		references = append(references, reference{pos: pos, sym: sym, kind: kind})
+	}
+}
+
+// CheckRenameTarget returns an error if the target var's namespace
+// already has a (non-fake) var named newName.
+func CheckRenameTarget(newName string) error {
+	if newName == "" || strings.ContainsAny(newName, "/ \t\n,;\"'`~@^()[]{}\\") {
+		return errors.New("Invalid var name: " + newName)
+	}
+	if ns := GLOBAL_ENV.Namespaces[refsTarget.ns]; ns != nil {
+		if vr, ok := ns.mappings[STRINGS.Intern(newName)]; ok && !vr.isFake {
+			return errors.New("Cannot rename " + refsTarget.ToString(false) + ": " + vr.ToString(false) + " already exists")
+		}
+	}
+	return nil
+}
+
+// referSites finds the symbols naming the target var in :refer, :only
+// and :rename of the libspecs of its namespace in ns forms.
+func (st *sourceText) referSites(forms []Object) []reference {
+	var res []reference
+	add := func(obj Object) {
+		if sym, ok := obj.(Symbol); ok && sym.ns == nil && *sym.name == *refsTarget.name {
+			res = append(res, reference{pos: sym.GetInfo().Position, sym: sym, kind: "refer"})
+		}
+	}
+	for _, form := range forms {
+		seq, ok := form.(Seq)
+		if !ok || seq.IsEmpty() || !isSymbolNamed(seq.First(), "ns") {
+			continue
+		}
+		for _, clause := range ToSlice(seq.Rest()) {
+			clause, ok := clause.(Seq)
+			if !ok || clause.IsEmpty() || !(clause.First().Equals(KEYWORDS.require) || clause.First().Equals(MakeKeyword("use"))) {
+				continue
+			}
+			for _, libspec := range ToSlice(clause.Rest()) {
+				v, ok := libspec.(Vec)
+				if !ok || v.Count() == 0 || !isSymbolNamed(v.At(0), *refsTarget.ns) {
+					continue
+				}
+				for i := 1; i < v.Count()-1; i++ {
+					switch opt := v.At(i + 1).(type) {
+					case Vec:
+						if v.At(i).Equals(MakeKeyword("refer")) || v.At(i).Equals(MakeKeyword("only")) {
+							for j := 0; j < opt.Count(); j++ {
+								add(opt.At(j))
+							}
+						}
+					case Map:
+						if v.At(i).Equals(MakeKeyword("rename")) {
+							for iter := opt.Iter(); iter.HasNext(); {
+								add(iter.Next().Key)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// symbolSpan returns the byte offsets of sym, read at pos, if that is
+// where its text is found in the source.
+func (st *sourceText) symbolSpan(pos Position, sym Symbol) (int, int, bool) {
+	start, ok := st.offset(pos.startLine, pos.startColumn)
+	if !ok {
+		return 0, 0, false
+	}
+	text := sym.ToString(false)
+	if !strings.HasPrefix(st.src[start:], text) {
+		return 0, 0, false
+	}
+	end := start + len(text)
+	if end < len(st.src) {
+		// The symbol must not continue past the expected text.
+		if r, _ := utf8.DecodeRuneInString(st.src[end:]); !isDelimiter(r) {
+			return 0, 0, false
+		}
+	}
+	return start, end, true
+}
+
+// fileReferences returns the references to the target var in filename,
+// whose contents are src, in order, along with their spans.
+func fileReferences(filename string, src string) (*sourceText, []reference, [][2]int, error) {
+	st := newSourceText(src)
+	forms, err := st.readForms(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var refs []reference
+	for _, ref := range references {
+		if ref.pos.Filename() == filename {
+			refs = append(refs, ref)
+		}
+	}
+	refs = append(refs, st.referSites(forms)...)
+	sort.SliceStable(refs, func(i, j int) bool {
+		pi, pj := refs[i].pos, refs[j].pos
+		if pi.startLine != pj.startLine {
+			return pi.startLine < pj.startLine
+		}
+		return pi.startColumn < pj.startColumn
+	})
+	var res []reference
+	var spans [][2]int
+	for _, ref := range refs {
+		start, end, ok := st.symbolSpan(ref.pos, ref.sym)
+		if !ok || (len(spans) > 0 && spans[len(spans)-1][0] == start) {
+			// Not found in the source (e.g. created by a macro) or already seen.
+			continue
+		}
+		res = append(res, ref)
+		spans = append(spans, [2]int{start, end})
+	}
+	return st, res, spans, nil
+}
+
+// FindReferences returns the definitions of and references to the
+// target var in filename, whose contents are src, one line per site.
+func FindReferences(filename string, src string) ([]string, error) {
+	_, refs, _, err := fileReferences(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(refs))
+	for i, ref := range refs {
+		res[i] = fmt.Sprintf("%s:%d:%d: %s %s", filename, ref.pos.startLine, ref.pos.startColumn, ref.kind, ref.sym.ToString(false))
+	}
+	return res, nil
+}
+
+// RenameReferences renames the target var to newName in filename, whose
+// contents are src, keeping the namespace (or alias) of qualified
+// references, and returns the new source and a summary of the changes.
+// References through a name given by :rename are left as is, since
+// renaming the key of :rename is enough.
+func RenameReferences(filename string, src string, newName string) (string, []string, error) {
+	st, refs, spans, err := fileReferences(filename, src)
+	if err != nil {
+		return src, nil, err
+	}
+	var b strings.Builder
+	var summary []string
+	prev := 0
+	for i, ref := range refs {
+		if *ref.sym.name != *refsTarget.name {
+			continue
+		}
+		text := newName
+		if ref.sym.ns != nil {
+			text = *ref.sym.ns + "/" + newName
+		}
+		b.WriteString(st.src[prev:spans[i][0]])
+		b.WriteString(text)
+		prev = spans[i][1]
+		summary = append(summary, fmt.Sprintf("%s:%d:%d: Renamed %s to %s", filename, ref.pos.startLine, ref.pos.startColumn, ref.sym.ToString(false), text))
+	}
+	b.WriteString(st.src[prev:])
+	return b.String(), summary, nil
+}
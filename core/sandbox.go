@@ -0,0 +1,172 @@
+package core
+
+import "strings"
+
+type (
+	// Sandbox restricts the vars code may refer to. The check is done
+	// when code is parsed, so code that refers to a var that is not
+	// allowed is rejected before any of it is evaluated.
+	//
+	// Vars in built-in namespaces (the ones Joker starts with, such as
+	// joker.core and joker.os) are allowed only if the var or its
+	// namespace is in the allowlist. Vars in other namespaces (user,
+	// namespaces created by sandboxed code or by the embedding program)
+	// are allowed unless denied.
+	// A denied var cannot be used even if its namespace is allowed.
+	// joker.core's internal procs (named with a __ suffix, such as
+	// spit__ and find-var__) are denied unless allowed by name, except
+	// in the expansions of joker.core macros, which use them.
+	//
+	// Built-in namespaces are shared by all interpreters, so sandboxed
+	// code cannot switch to them (to def or refer vars in them) or
+	// change their metadata or that of their vars at run time.
+	//
+	// A Sandbox must not be modified after it is installed.
+	Sandbox struct {
+		allowed map[string]bool
+		denied  map[string]bool
+	}
+)
+
+// Namespaces allowed by NewSandbox. They do not access files,
+// processes, or the network.
+var SandboxAllowedNamespaces = []string{
+	"joker.base64",
+	"joker.better-cond",
+	"joker.core",
+	"joker.crypto",
+	"joker.csv",
+	"joker.hex",
+	"joker.hiccup",
+	"joker.html",
+	"joker.json",
+	"joker.markdown",
+	"joker.math",
+	"joker.pprint",
+	"joker.set",
+	"joker.strconv",
+	"joker.string",
+	"joker.template",
+	"joker.time",
+	"joker.url",
+	"joker.uuid",
+	"joker.walk",
+	"joker.yaml",
+}
+
+// Vars denied by NewSandbox, because they access files or the
+// environment, or give access to arbitrary vars at run time
+// (bypassing the parse-time check).
+var SandboxDeniedVars = []string{
+	"joker.core/Go",
+	"joker.core/exit",
+	"joker.core/find-var",
+	"joker.core/intern",
+	"joker.core/load",
+	"joker.core/load-file",
+	"joker.core/ns-interns",
+	"joker.core/ns-map",
+	"joker.core/ns-publics",
+	"joker.core/ns-refers",
+	"joker.core/ns-resolve",
+	"joker.core/ns-sources",
+	"joker.core/ns-unmap",
+	"joker.core/remove-ns",
+	"joker.core/requiring-resolve",
+	"joker.core/resolve",
+	"joker.core/slurp",
+	"joker.core/spit",
+}
+
+// NewSandbox returns a sandbox that allows SandboxAllowedNamespaces
+// except SandboxDeniedVars.
+func NewSandbox() *Sandbox {
+	sb := &Sandbox{
+		allowed: make(map[string]bool),
+		denied:  make(map[string]bool),
+	}
+	sb.Allow(SandboxAllowedNamespaces...)
+	sb.Deny(SandboxDeniedVars...)
+	return sb
+}
+
+// Allow adds namespaces (e.g. "joker.os") and fully qualified vars
+// (e.g. "joker.os/env") to the allowlist.
+func (sb *Sandbox) Allow(names ...string) {
+	for _, name := range names {
+		sb.allowed[name] = true
+		delete(sb.denied, name)
+	}
+}
+
+// Deny removes namespaces and vars from the allowlist.
+func (sb *Sandbox) Deny(names ...string) {
+	for _, name := range names {
+		sb.denied[name] = true
+		delete(sb.allowed, name)
+	}
+}
+
+func isBuiltinNamespace(ns *Namespace) bool {
+	return sharedNamespaces[ns.Name.name] == ns
+}
+
+// Allows reports whether code may refer to vr.
+func (sb *Sandbox) Allows(vr *Var) bool {
+	if vr.ns == nil {
+		return true
+	}
+	ns := vr.ns.Name.Name()
+	name := ns + "/" + vr.name.Name()
+	switch {
+	case sb.denied[name]:
+		return false
+	case sb.allowed[name]:
+		return true
+	case sb.denied[ns]:
+		return false
+	case isInternalProc(vr):
+		return false
+	}
+	return sb.allowed[ns] || !isBuiltinNamespace(vr.ns)
+}
+
+// isInternalProc reports whether vr is one of joker.core's internal
+// procs, which the public functions wrap.
+func isInternalProc(vr *Var) bool {
+	return vr.ns.Name.Name() == "joker.core" && strings.HasSuffix(vr.name.Name(), "__")
+}
+
+// SetSandbox restricts code parsed in env from now on to the vars sb
+// allows. A nil sb lifts the restrictions.
+// Must be called with the GIL held, before evaluating any code
+// other than joker.core's.
+func (env *Env) SetSandbox(sb *Sandbox) {
+	captureSharedNamespaces()
+	env.sandbox = sb
+}
+
+func (env *Env) Sandbox() *Sandbox {
+	return env.sandbox
+}
+
+// checkBuiltinNamespaceChange panics if env is sandboxed, since changing
+// ns (what it refers to, or its metadata) would change it for all
+// interpreters. The values of its vars are not shared, so setting
+// them is allowed.
+func (env *Env) checkBuiltinNamespaceChange(ns *Namespace, what string) {
+	if env.sandbox != nil && ns != nil && isBuiltinNamespace(ns) {
+		panic(RT.NewError(what + " " + ns.Name.Name() + " is not allowed in sandbox mode"))
+	}
+}
+
+func (env *Env) checkSandbox(vr *Var, obj Object) {
+	if env.sandbox == nil || LINTER_MODE || env.sandbox.Allows(vr) {
+		return
+	}
+	if isInternalProc(vr) && !env.sandbox.denied[vr.ns.Name.Name()+"/"+vr.name.Name()] && GetPosition(obj).filename == STR.coreFilename {
+		// Expanded from a joker.core macro.
+		return
+	}
+	panic(&ParseError{obj: obj, msg: "Var " + vr.ns.Name.Name() + "/" + vr.name.Name() + " is not allowed in sandbox mode"})
+}
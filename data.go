@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	. "github.com/candid82/joker/core"
+)
+
+// Formats of standard input (--in) and of the result of --eval (--out).
+var (
+	inFormats  = []string{"edn", "json", "lines", "csv"}
+	outFormats = []string{"edn", "json", "lines"}
+	// Formats of the forms and expressions printed by --read/--parse --dump.
+	dumpFormats = []string{"edn", "json"}
+	// Formats of the namespace dependency graph printed by --deps-graph.
+	depsFormats = []string{"dot", "edn"}
+	// Formats of the API docs written by --doc.
+	docFormats = []string{"html", "markdown"}
+)
+
+func checkDataFormat(option, format string, formats []string) {
+	for _, f := range formats {
+		if f == format {
+			return
+		}
+	}
+	fmt.Fprintf(Stderr, "Error: Unknown %s format '%s' (expected one of: %s)\n", option, format, strings.Join(formats, ", "))
+	ExitJoker(2)
+}
+
+func resolveVar(name string) *Var {
+	vr, ok := GLOBAL_ENV.Resolve(MakeSymbol(name))
+	if !ok {
+		panic(RT.NewError("Unable to resolve var " + name))
+	}
+	return vr
+}
+
+func callVar(name string, args ...Object) Object {
+	return resolveVar(name).Call(args)
+}
+
+// ednSeq returns a lazy sequence of the forms read from r.
+func ednSeq(r *Reader) Seq {
+	var c = func(args []Object) Object {
+		obj, err := TryRead(r)
+		if err == io.EOF {
+			return EmptyList
+		}
+		PanicOnErr(err)
+		return NewConsSeq(obj, ednSeq(r))
+	}
+	return NewLazySeq(Proc{Fn: c, Name: "edn-seq"})
+}
+
+// bindInput defines user/*input* as the contents of *in* parsed
+// according to format. Input in all formats is read lazily, as
+// a sequence of values: forms (edn), JSON values with keys converted
+// to keywords (json), lines (lines), or records (csv).
+func bindInput(format string) {
+	in := resolveVar("joker.core/*in*").Resolve(false)
+	var input Object
+	switch format {
+	case "edn":
+		input = ednSeq(NewReader(in.(io.RuneReader), "<stdin>"))
+	case "json":
+		opts := EmptyArrayMap()
+		opts.Add(MakeKeyword("keywords?"), Boolean{B: true})
+		input = callVar("joker.json/json-seq", in, opts)
+	case "lines":
+		input = callVar("joker.core/line-seq", in)
+	case "csv":
+		input = callVar("joker.csv/csv-seq", in)
+	}
+	ns := GLOBAL_ENV.EnsureSymbolIsNamespace(MakeSymbol("user"))
+	ns.InternVar("*input*", input, MakeMeta(nil, "Standard input, parsed according to --in "+format+".", "1.0"))
+}
+
+// printOutput prints the result of --eval according to format.
+// Printing realizes lazy sequences, which can fail (e.g. on invalid
+// input), so errors are reported as they are for evaluation.
+func printOutput(obj Object, format string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *EvalError, *StackOverflowError, *ExInfo:
+				err = r.(error)
+				fmt.Fprintln(Stderr, err)
+			default:
+				panic(r)
+			}
+		}
+	}()
+	switch format {
+	case "edn":
+		fmt.Fprintln(Stdout, obj.ToString(true))
+	case "json":
+		opts := EmptyArrayMap()
+		opts.Add(MakeKeyword("escape-html?"), Boolean{B: false})
+		fmt.Fprintln(Stdout, callVar("joker.json/write-string", obj, opts).ToString(false))
+	case "lines":
+		switch obj := obj.(type) {
+		case Nil:
+		case String:
+			fmt.Fprintln(Stdout, obj.S)
+		case Seqable:
+			for s := obj.Seq(); !s.IsEmpty(); s = s.Rest() {
+				fmt.Fprintln(Stdout, s.First().ToString(false))
+			}
+		default:
+			fmt.Fprintln(Stdout, obj.ToString(false))
+		}
+	}
+	return nil
+}
+
+// printDump prints a form or expression dumped by --read/--parse.
+func printDump(obj Object) {
+	printOutput(obj, dumpFormat)
+}
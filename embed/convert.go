@@ -0,0 +1,347 @@
+package embed
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/candid82/joker/core"
+)
+
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToObject converts a Go value to a Joker object:
+//
+//	nil                        nil
+//	bool                       Boolean
+//	integers                   Int (BigInt if it doesn't fit)
+//	floats                     Double
+//	string, []byte             String
+//	*big.Int, *big.Rat         BigInt, Ratio
+//	time.Time                  Time
+//	slices and arrays          Vector
+//	maps                       Map
+//	pointers                   the value pointed to
+//	functions                  Fn (see Interpreter.Define)
+//	core.Object                the object itself
+//
+// Other values (e.g. structs) cannot be converted.
+func ToObject(v interface{}) (core.Object, error) {
+	return toObject(v, "fn")
+}
+
+func toObject(v interface{}, name string) (core.Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return core.NIL, nil
+	case core.Object:
+		return v, nil
+	case []byte:
+		return core.MakeString(string(v)), nil
+	case *big.Int:
+		return core.MakeBigInt(v), nil
+	case *big.Rat:
+		return core.MakeRatio(v), nil
+	case time.Time:
+		return core.MakeTime(v), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return core.Boolean{B: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return core.MakeInt(int(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			return core.MakeBigInt(new(big.Int).SetUint64(u)), nil
+		}
+		return core.MakeInt(int(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return core.MakeDouble(rv.Float()), nil
+	case reflect.String:
+		return core.MakeString(rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		res := core.EmptyVector()
+		for i := 0; i < rv.Len(); i++ {
+			obj, err := toObject(rv.Index(i).Interface(), name)
+			if err != nil {
+				return nil, err
+			}
+			res = res.Conjoin(obj)
+		}
+		return res, nil
+	case reflect.Map:
+		var res core.Map = core.EmptyArrayMap()
+		for iter := rv.MapRange(); iter.Next(); {
+			k, err := toObject(iter.Key().Interface(), name)
+			if err != nil {
+				return nil, err
+			}
+			v, err := toObject(iter.Value().Interface(), name)
+			if err != nil {
+				return nil, err
+			}
+			res = res.Assoc(k, v).(core.Map)
+		}
+		return res, nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return core.NIL, nil
+		}
+		return toObject(rv.Elem().Interface(), name)
+	case reflect.Func:
+		if rv.IsNil() {
+			return core.NIL, nil
+		}
+		return wrapFunc(name, rv), nil
+	}
+	return nil, fmt.Errorf("Cannot convert %T to Joker object", v)
+}
+
+// FromObject converts a Joker object to a Go value:
+//
+//	nil                        nil
+//	Boolean                    bool
+//	Int, BigInt                int, *big.Int
+//	Double, Ratio              float64, *big.Rat
+//	String, Char               string, rune
+//	Keyword, Symbol            string (without the colon)
+//	Time                       time.Time
+//	Map                        map[string]interface{} if all keys are
+//	                           strings, keywords or symbols; otherwise
+//	                           map[interface{}]interface{}
+//	other collections          []interface{}
+//
+// Other objects (e.g. functions) are returned as is, so they can be
+// passed back to Joker.
+// Converting lazy sequences realizes them, so FromObject must be called
+// with the GIL held (e.g. within core.Interpreter.Run).
+func FromObject(obj core.Object) interface{} {
+	switch obj := obj.(type) {
+	case core.Nil:
+		return nil
+	case core.Boolean:
+		return obj.B
+	case core.Int:
+		return obj.I
+	case *core.BigInt:
+		return obj.BigInt()
+	case core.Double:
+		return obj.D
+	case *core.Ratio:
+		return obj.Ratio()
+	case core.String:
+		return obj.S
+	case core.Char:
+		return obj.Ch
+	case core.Keyword:
+		return obj.ToString(false)[1:]
+	case core.Symbol:
+		return obj.ToString(false)
+	case core.Time:
+		return obj.T
+	case core.Map:
+		if res, ok := stringKeyMap(obj); ok {
+			return res
+		}
+		res := make(map[interface{}]interface{}, obj.Count())
+		for iter := obj.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			res[FromObject(p.Key)] = FromObject(p.Value)
+		}
+		return res
+	case core.Seqable:
+		res := []interface{}{}
+		for s := obj.Seq(); !s.IsEmpty(); s = s.Rest() {
+			res = append(res, FromObject(s.First()))
+		}
+		return res
+	}
+	return obj
+}
+
+func stringKeyMap(m core.Map) (map[string]interface{}, bool) {
+	res := make(map[string]interface{}, m.Count())
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		switch p.Key.(type) {
+		case core.String, core.Keyword, core.Symbol:
+			res[FromObject(p.Key).(string)] = FromObject(p.Value)
+		default:
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// fromObjectTo converts obj to a Go value of type t.
+// Parameters of type core.Object (or another interface obj implements,
+// such as core.Callable) get obj itself; interface{} parameters get
+// FromObject(obj).
+func fromObjectTo(obj core.Object, t reflect.Type) (reflect.Value, error) {
+	ot := reflect.TypeOf(obj)
+	if ot == t || (t.Kind() == reflect.Interface && t != interfaceType && ot.Implements(t)) {
+		return reflect.ValueOf(obj), nil
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if s, ok := obj.(core.String); ok && t.Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf([]byte(s.S)).Convert(t), nil
+		}
+		if s, ok := obj.(core.Seqable); ok {
+			res := reflect.MakeSlice(t, 0, 0)
+			for s := s.Seq(); !s.IsEmpty(); s = s.Rest() {
+				v, err := fromObjectTo(s.First(), t.Elem())
+				if err != nil {
+					return v, err
+				}
+				res = reflect.Append(res, v)
+			}
+			return res, nil
+		}
+	case reflect.Map:
+		if m, ok := obj.(core.Map); ok {
+			res := reflect.MakeMapWithSize(t, m.Count())
+			for iter := m.Iter(); iter.HasNext(); {
+				p := iter.Next()
+				k, err := fromObjectTo(p.Key, t.Key())
+				if err != nil {
+					return k, err
+				}
+				v, err := fromObjectTo(p.Value, t.Elem())
+				if err != nil {
+					return v, err
+				}
+				res.SetMapIndex(k, v)
+			}
+			return res, nil
+		}
+	}
+	v := FromObject(obj)
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, conversionError(obj, t)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		res := reflect.New(t).Elem()
+		res.Set(rv)
+		return res, nil
+	}
+	res := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := v.(int); ok && !res.OverflowInt(int64(i)) {
+			res.SetInt(int64(i))
+			return res, nil
+		}
+		if ch, ok := v.(rune); ok && !res.OverflowInt(int64(ch)) {
+			res.SetInt(int64(ch))
+			return res, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := v.(int); ok && i >= 0 && !res.OverflowUint(uint64(i)) {
+			res.SetUint(uint64(i))
+			return res, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := obj.(core.Number); ok {
+			res.SetFloat(n.Double().D)
+			return res, nil
+		}
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			res.SetString(s)
+			return res, nil
+		}
+	}
+	return reflect.Value{}, conversionError(obj, t)
+}
+
+func conversionError(obj core.Object, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", obj.GetType().ToString(false), t)
+}
+
+func paramType(t reflect.Type, i int) reflect.Type {
+	if t.IsVariadic() && i >= t.NumIn()-1 {
+		return t.In(t.NumIn() - 1).Elem()
+	}
+	return t.In(i)
+}
+
+// wrapFunc makes a Joker function calling the Go function fn.
+// Arguments are converted to the types of fn's parameters (see fromObjectTo).
+// A non-nil error returned as the last result is thrown as a Joker
+// error; other results are converted with ToObject (a vector of them
+// if there are several).
+// fn is called with the GIL held, so it must not block for long.
+func wrapFunc(name string, fn reflect.Value) core.Proc {
+	t := fn.Type()
+	min, max := t.NumIn(), t.NumIn()
+	if t.IsVariadic() {
+		min, max = min-1, 999
+	}
+	return core.Proc{Fn: func(args []core.Object) core.Object {
+		n := len(args)
+		if n < min || n > max {
+			panic(core.RT.NewError(fmt.Sprintf("Wrong number of args (%d) passed to %s; expects %s", n, name, core.RangeString(min, max))))
+		}
+		in := make([]reflect.Value, n)
+		for i, arg := range args {
+			v, err := fromObjectTo(arg, paramType(t, i))
+			if err != nil {
+				panic(core.RT.NewError(fmt.Sprintf("Arg[%d] of %s: %s", i, name, err)))
+			}
+			in[i] = v
+		}
+		out := fn.Call(in)
+		if k := len(out); k > 0 && t.Out(k-1) == errorType {
+			if err := out[k-1].Interface(); err != nil {
+				panic(core.RT.NewError(err.(error).Error()))
+			}
+			out = out[:k-1]
+		}
+		res := make([]core.Object, len(out))
+		for i, v := range out {
+			obj, err := toObject(v.Interface(), name)
+			if err != nil {
+				panic(core.RT.NewError(fmt.Sprintf("Result of %s: %s", name, err)))
+			}
+			res[i] = obj
+		}
+		switch len(res) {
+		case 0:
+			return core.NIL
+		case 1:
+			return res[0]
+		}
+		return core.NewVectorFrom(res...)
+	}, Name: name, Package: "embed"}
+}
+
+// arglists describes the parameters of a Go function for the
+// :arglists metadata of the var it is bound to.
+func arglists(v interface{}) core.Seq {
+	if v == nil || reflect.TypeOf(v).Kind() != reflect.Func {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	var params []core.Object
+	for i := 0; i < t.NumIn(); i++ {
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			params = append(params, core.MakeSymbol("&"), core.MakeSymbol("more"))
+		} else {
+			params = append(params, core.MakeSymbol("arg"+strconv.Itoa(i+1)))
+		}
+	}
+	return core.NewListFrom(core.NewVectorFrom(params...))
+}
@@ -0,0 +1,188 @@
+// Package embed provides a stable API for using Joker as a scripting
+// language in Go programs.
+//
+// An Interpreter evaluates Joker code in an environment of its own
+// (see core.Interpreter), so several of them can coexist in one
+// program. Values passed between Go and Joker are converted
+// automatically (see ToObject and FromObject), and Go functions can
+// be made available to Joker code with Define.
+//
+// Importing this package also loads Joker's standard library
+// (joker.string, joker.json, joker.http, etc.).
+package embed
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/candid82/joker/core"
+	_ "github.com/candid82/joker/std/base64"
+	_ "github.com/candid82/joker/std/bolt"
+	_ "github.com/candid82/joker/std/crypto"
+	_ "github.com/candid82/joker/std/csv"
+	_ "github.com/candid82/joker/std/filepath"
+	_ "github.com/candid82/joker/std/git"
+	_ "github.com/candid82/joker/std/hex"
+	_ "github.com/candid82/joker/std/html"
+	_ "github.com/candid82/joker/std/http"
+	_ "github.com/candid82/joker/std/io"
+	_ "github.com/candid82/joker/std/json"
+	_ "github.com/candid82/joker/std/markdown"
+	_ "github.com/candid82/joker/std/math"
+	_ "github.com/candid82/joker/std/os"
+	_ "github.com/candid82/joker/std/runtime"
+	_ "github.com/candid82/joker/std/strconv"
+	_ "github.com/candid82/joker/std/string"
+	_ "github.com/candid82/joker/std/time"
+	_ "github.com/candid82/joker/std/url"
+	_ "github.com/candid82/joker/std/uuid"
+	_ "github.com/candid82/joker/std/yaml"
+)
+
+type (
+	// Options configure a new Interpreter. Nil streams default to
+	// os.Stdin, os.Stdout and os.Stderr.
+	Options struct {
+		Stdin  io.Reader
+		Stdout io.Writer
+		Stderr io.Writer
+		// Sandbox, if not nil, restricts the vars code may use, e.g.
+		// to run untrusted scripts (see core.NewSandbox).
+		Sandbox *core.Sandbox
+		// Limits apply to each call of Eval, LoadFile, Call, etc.
+		Limits core.EvalLimits
+	}
+
+	// Interpreter evaluates Joker code. It is safe for concurrent use.
+	Interpreter struct {
+		in *core.Interpreter
+	}
+)
+
+// New creates an interpreter with a fresh user namespace.
+func New(opts Options) *Interpreter {
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	in := core.NewInterpreter(opts.Stdin, opts.Stdout, opts.Stderr)
+	if opts.Sandbox != nil {
+		in.SetSandbox(opts.Sandbox)
+	}
+	in.SetLimits(opts.Limits)
+	return &Interpreter{in: in}
+}
+
+// Core returns the underlying core interpreter, for uses not covered
+// by this package.
+func (i *Interpreter) Core() *core.Interpreter {
+	return i.in
+}
+
+// Close discards the interpreter. Subsequent calls return
+// core.ErrInterpreterClosed.
+func (i *Interpreter) Close() {
+	i.in.Close()
+}
+
+// Eval evaluates all forms in code and returns the value of the last
+// one, converted with FromObject.
+func (i *Interpreter) Eval(code string) (interface{}, error) {
+	return i.fromObject(i.in.Eval(code))
+}
+
+// LoadFile evaluates all forms in the file and returns the value of
+// the last one, converted with FromObject.
+func (i *Interpreter) LoadFile(filename string) (interface{}, error) {
+	return i.fromObject(i.in.LoadFile(filename))
+}
+
+// Call calls the function the var named name (optionally qualified
+// with a namespace or alias, e.g. "joker.string/join") resolves to.
+// Arguments are converted with ToObject and the result with FromObject.
+func (i *Interpreter) Call(name string, args ...interface{}) (interface{}, error) {
+	objs, err := toObjects(args)
+	if err != nil {
+		return nil, err
+	}
+	return i.fromObject(i.in.Run(func() core.Object {
+		vr, ok := i.in.Env().Resolve(core.MakeSymbol(name))
+		if !ok {
+			panic(core.RT.NewError("Unable to resolve symbol: " + name))
+		}
+		return vr.Call(objs)
+	}))
+}
+
+// Apply calls f, which must be a Joker function (e.g. one returned by
+// Eval or Call), with arguments converted by ToObject.
+func (i *Interpreter) Apply(f interface{}, args ...interface{}) (interface{}, error) {
+	fn, ok := f.(core.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a Joker function", f)
+	}
+	objs, err := toObjects(args)
+	if err != nil {
+		return nil, err
+	}
+	return i.fromObject(i.in.Run(func() core.Object {
+		return fn.Call(objs)
+	}))
+}
+
+// Define binds the var named name to value converted with ToObject.
+// An unqualified name is interned in the user namespace, a qualified
+// one in its namespace, which is created if needed. Go functions
+// become Joker functions that check the number and types of the
+// arguments they are called with.
+//
+// Vars defined in namespaces Joker starts with (such as joker.core)
+// are visible to all interpreters, so prefer namespaces of your own.
+func (i *Interpreter) Define(name string, value interface{}) error {
+	sym := core.MakeSymbol(name)
+	obj, err := toObject(value, name)
+	if err != nil {
+		return err
+	}
+	_, err = i.in.Run(func() core.Object {
+		env := i.in.Env()
+		ns := env.FindNamespace(core.MakeSymbol("user"))
+		if sym.Namespace() != "" {
+			ns = env.EnsureSymbolIsNamespace(core.MakeSymbol(sym.Namespace()))
+		}
+		return ns.InternVar(sym.Name(), obj, core.MakeMeta(arglists(value), "", ""))
+	})
+	return err
+}
+
+// fromObject converts the result of evaluation with the interpreter
+// active, as converting lazy sequences may run Joker code.
+func (i *Interpreter) fromObject(obj core.Object, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	_, err = i.in.Run(func() core.Object {
+		res = FromObject(obj)
+		return core.NIL
+	})
+	return res, err
+}
+
+func toObjects(args []interface{}) ([]core.Object, error) {
+	objs := make([]core.Object, len(args))
+	for n, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("Arg[%d]: %s", n, err)
+		}
+		objs[n] = obj
+	}
+	return objs, nil
+}
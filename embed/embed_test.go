@@ -0,0 +1,218 @@
+package embed
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/candid82/joker/core"
+)
+
+func newTestInterpreter(t *testing.T) (*Interpreter, *bytes.Buffer) {
+	var out bytes.Buffer
+	i := New(Options{Stdin: strings.NewReader(""), Stdout: &out, Stderr: &out})
+	t.Cleanup(i.Close)
+	return i, &out
+}
+
+func TestEval(t *testing.T) {
+	i, out := newTestInterpreter(t)
+	res, err := i.Eval(`(println "hi") {:a [1 2.5 "s" \c nil true]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"a": []interface{}{1, 2.5, "s", 'c', nil, true}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %#v, expected %#v", res, expected)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if _, err := i.Eval("(inc nil)"); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := i.Eval("(+ 1"); err == nil {
+		t.Error("expected a read error")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	i, _ := newTestInterpreter(t)
+	filename := filepath.Join(t.TempDir(), "script.joke")
+	if err := os.WriteFile(filename, []byte("(ns script) (defn greet [s] (str \"Hello, \" s))"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.LoadFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	res, err := i.Call("script/greet", "world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "Hello, world" {
+		t.Errorf("got %#v", res)
+	}
+}
+
+func TestCall(t *testing.T) {
+	i, _ := newTestInterpreter(t)
+	res, err := i.Call("joker.string/join", ",", []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "a,b" {
+		t.Errorf("got %#v", res)
+	}
+	res, err = i.Call("merge", map[string]int{"a": 1}, map[string]int{"b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, map[string]interface{}{"a": 1, "b": 2}) {
+		t.Errorf("got %#v", res)
+	}
+	f, err := i.Eval("(fn [x] (* x 2))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = i.Apply(f, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != 42 {
+		t.Errorf("got %#v", res)
+	}
+	if _, err := i.Call("no-such-fn"); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := i.Call("str", struct{}{}); err == nil {
+		t.Error("expected a conversion error")
+	}
+}
+
+func TestDefine(t *testing.T) {
+	i, _ := newTestInterpreter(t)
+	if err := i.Define("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Define("my.lib/concat", func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Define("fail", func(msg string) (int, error) { return 0, errors.New(msg) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Define("config", map[string]interface{}{"debug": true}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		code     string
+		expected interface{}
+	}{
+		{"(add 1 2)", 3},
+		{"(my.lib/concat \"-\" \"a\" \"b\" \"c\")", "a-b-c"},
+		{"(my.lib/concat \",\")", ""},
+		{"(get config \"debug\")", true},
+		{"(:arglists (meta #'add))", []interface{}{[]interface{}{"arg1", "arg2"}}},
+		{"(try (fail \"boom\") (catch Error e (ex-message e)))", "boom"},
+	}
+	for _, test := range tests {
+		res, err := i.Eval(test.code)
+		if err != nil {
+			t.Errorf("%s: %s", test.code, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: got %#v, expected %#v", test.code, res, test.expected)
+		}
+	}
+	for _, code := range []string{"(add 1)", "(add 1 2 3)", "(add 1 \"2\")", "(my.lib/concat)"} {
+		if _, err := i.Eval(code); err == nil {
+			t.Errorf("%s: expected an error", code)
+		}
+	}
+	_, err := i.Eval("(add 1)")
+	if err == nil || !strings.Contains(err.Error(), "Wrong number of args (1) passed to add; expects 2") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	i1, _ := newTestInterpreter(t)
+	i2, _ := newTestInterpreter(t)
+	if err := i1.Define("x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i2.Eval("x"); err == nil {
+		t.Error("x should not be defined in another interpreter")
+	}
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			in := i1
+			if n%2 == 0 {
+				in = i2
+			}
+			if res, err := in.Call("inc", n); err != nil || res != n+1 {
+				t.Errorf("got %#v, %v", res, err)
+			}
+		}(n)
+	}
+	wg.Wait()
+	i1.Close()
+	if _, err := i1.Eval("1"); err == nil {
+		t.Error("expected an error after Close")
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	sb := core.NewSandbox()
+	sb.Allow("joker.os/env")
+	i := New(Options{Sandbox: sb, Limits: core.EvalLimits{MaxSteps: 100000, Timeout: time.Second}})
+	defer i.Close()
+	if err := i.Define("app/double", func(n int) int { return n * 2 }); err != nil {
+		t.Fatal(err)
+	}
+	res, err := i.Eval(`(ns script (:require [joker.string :as s])) (s/join "," [(app/double 2) (map? (joker.os/env))])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "4,true" {
+		t.Errorf("got %#v", res)
+	}
+	for _, code := range []string{
+		`(slurp "/etc/passwd")`,
+		`(joker.os/sh "ls")`,
+		`(resolve 'joker.os/sh)`,
+		`((requiring-resolve 'joker.os/sh) "ls")`,
+		`(ns-resolve 'joker.os 'sh)`,
+		`(in-ns 'joker.core)`,
+		`(var-set #'*ns* (the-ns 'joker.string))`,
+		`(alter-meta! #'joker.core/map assoc :private true)`,
+	} {
+		_, err := i.Eval(code)
+		if err == nil || !strings.Contains(err.Error(), "is not allowed in sandbox mode") {
+			t.Errorf("%s: unexpected error %v", code, err)
+		}
+	}
+	_, err = i.Eval("(try (loop [] (recur)) (catch Error e :caught))")
+	if err == nil || !strings.Contains(err.Error(), "limit of 100000 steps") {
+		t.Errorf("unexpected error %v", err)
+	}
+	// Each evaluation gets a fresh budget.
+	if res, err := i.Eval("(count (range 100))"); err != nil || res != 100 {
+		t.Errorf("got %#v, %v", res, err)
+	}
+	other, _ := newTestInterpreter(t)
+	if _, err := other.Eval("(loop [n 0] (if (< n 200000) (recur (inc n)) (slurp \"/dev/null\")))"); err != nil {
+		t.Errorf("limits and sandbox should not apply to other interpreters: %v", err)
+	}
+}
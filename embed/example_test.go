@@ -0,0 +1,36 @@
+package embed_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/candid82/joker/embed"
+)
+
+func Example() {
+	i := embed.New(embed.Options{})
+	defer i.Close()
+
+	i.Define("app/shout", func(s string) string {
+		return strings.ToUpper(s) + "!"
+	})
+	i.Eval(`(ns script)
+	        (defn greet [names] (mapv #(app/shout (str "hello, " %)) names))`)
+
+	res, err := i.Call("script/greet", []string{"alice", "bob"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(res)
+	// Output: [HELLO, ALICE! HELLO, BOB!]
+}
+
+func ExampleInterpreter_Eval() {
+	i := embed.New(embed.Options{})
+	defer i.Close()
+
+	res, _ := i.Eval("(reduce + (range 10))")
+	fmt.Println(res)
+	// Output: 45
+}
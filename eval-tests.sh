@@ -1,3 +1,9 @@
 #!/usr/bin/env bash
 
-./joker tests/run-eval-tests.joke "$@"
+# With --compile, the tests (including forked ones) are run with compilation on.
+flags=
+for arg in "$@"; do
+    [[ "$arg" == "--compile" ]] && flags=--compile
+done
+
+./joker $flags tests/run-eval-tests.joke "$@"
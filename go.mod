@@ -9,6 +9,7 @@ require (
 	github.com/pkg/profile v1.7.0
 	github.com/yuin/goldmark v1.5.6
 	go.etcd.io/bbolt v1.3.7
+	golang.org/x/net v0.12.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -33,7 +34,6 @@ require (
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	golang.org/x/crypto v0.11.0 // indirect
 	golang.org/x/mod v0.8.0 // indirect
-	golang.org/x/net v0.12.0 // indirect
 	golang.org/x/sys v0.10.0 // indirect
 	golang.org/x/tools v0.6.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
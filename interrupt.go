@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/signal"
+
+	. "github.com/candid82/joker/core"
+)
+
+// interruptOnSignal makes SIGINT (Ctrl-C) interrupt evaluation, rather
+// than terminate Joker, until the returned function is called.
+func interruptOnSignal() (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		for {
+			select {
+			case <-c:
+				Interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+const (
+	ctrlC      = 0x03
+	telnetIAC  = 0xff // "Interpret As Command"
+	telnetIP   = 0xf4 // "Interrupt Process"
+	telnetWILL = 0xfb // WILL, WONT, DO, and DONT (0xfb-0xfe) take an option byte
+	telnetSB   = 0xfa // subnegotiation, ended by IAC SE
+	telnetSE   = 0xf0
+)
+
+// States of telnet command parsing.
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSB
+	telnetStateSBIAC
+)
+
+// interruptibleInput returns a reader of the data read from r (a repl
+// client connection) with Ctrl-C characters and telnet commands
+// removed. Ctrl-C and telnet's "Interrupt Process" command (sent by
+// telnet when Ctrl-C is pressed) interrupt evaluation.
+// r is read in the background, so that interrupts are seen while
+// a form is being evaluated.
+func interruptibleInput(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		buf := make([]byte, 4096)
+		state := telnetStateData
+		for {
+			n, err := r.Read(buf)
+			out := buf[:0]
+			for _, b := range buf[:n] {
+				switch state {
+				case telnetStateIAC:
+					switch {
+					case b == telnetIAC:
+						out = append(out, b)
+						state = telnetStateData
+					case b == telnetIP:
+						Interrupt()
+						state = telnetStateData
+					case b == telnetSB:
+						state = telnetStateSB
+					case b >= telnetWILL:
+						state = telnetStateOption
+					default:
+						state = telnetStateData
+					}
+				case telnetStateOption:
+					state = telnetStateData
+				case telnetStateSB:
+					if b == telnetIAC {
+						state = telnetStateSBIAC
+					}
+				case telnetStateSBIAC:
+					if b == telnetSE {
+						state = telnetStateData
+					} else {
+						state = telnetStateSB
+					}
+				case telnetStateData:
+					switch b {
+					case ctrlC:
+						Interrupt()
+					case telnetIAC:
+						state = telnetStateIAC
+					default:
+						out = append(out, b)
+					}
+				}
+			}
+			if len(out) > 0 {
+				if _, err := pw.Write(out); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
@@ -8,11 +8,15 @@ import (
 	"math"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	. "github.com/candid82/joker/core"
 	_ "github.com/candid82/joker/std/base64"
@@ -76,10 +80,10 @@ func NewReplContext(env *Env) *ReplContext {
 	second, _ := env.Resolve(MakeSymbol("joker.core/*2"))
 	third, _ := env.Resolve(MakeSymbol("joker.core/*3"))
 	exc, _ := env.Resolve(MakeSymbol("joker.core/*e"))
-	first.Value = NIL
-	second.Value = NIL
-	third.Value = NIL
-	exc.Value = NIL
+	first.SetValue(NIL)
+	second.SetValue(NIL)
+	third.SetValue(NIL)
+	exc.SetValue(NIL)
 	return &ReplContext{
 		first:  first,
 		second: second,
@@ -89,13 +93,13 @@ func NewReplContext(env *Env) *ReplContext {
 }
 
 func (ctx *ReplContext) PushValue(obj Object) {
-	ctx.third.Value = ctx.second.Value
-	ctx.second.Value = ctx.first.Value
-	ctx.first.Value = obj
+	ctx.third.SetValue(ctx.second.Value)
+	ctx.second.SetValue(ctx.first.Value)
+	ctx.first.SetValue(obj)
 }
 
 func (ctx *ReplContext) PushException(exc Object) {
-	ctx.exc.Value = exc
+	ctx.exc.SetValue(exc)
 }
 
 func processFile(filename string, phase Phase) error {
@@ -135,6 +139,9 @@ func processFile(filename string, phase Phase) error {
 	if saveForRepl {
 		reader = NewReader(&replayable{reader}, "<replay>")
 	}
+	if dumpFormat != "" {
+		return DumpReader(reader, filename, phase, printDump)
+	}
 	return ProcessReader(reader, filename, phase)
 }
 
@@ -190,7 +197,10 @@ func processReplCommand(reader *Reader, phase Phase, parseContext *ParseContext,
 		return false
 	}
 
-	res := Eval(expr, nil)
+	SetEvalLimits(evalLimits)
+	ClearInterrupt()
+	defer interruptOnSignal()()
+	res := EvalTopLevel(expr)
 	replContext.PushValue(res)
 	PrintObject(res, Stdout)
 	fmt.Fprintln(Stdout, "")
@@ -220,10 +230,11 @@ func srepl(port string, phase Phase) {
 	oldStdOut := Stdout
 	oldStdErr := Stderr
 	oldStdinValue, oldStdoutValue, oldStderrValue := GLOBAL_ENV.StdIO()
-	Stdin = conn
+	input := interruptibleInput(conn)
+	Stdin = input
 	Stdout = conn
 	Stderr = conn
-	newIn := MakeBufferedReader(conn)
+	newIn := MakeBufferedReader(input)
 	newOut := MakeIOWriter(conn)
 	GLOBAL_ENV.SetStdIO(newIn, newOut, newOut)
 	defer func() {
@@ -236,7 +247,7 @@ func srepl(port string, phase Phase) {
 
 	fmt.Printf("Joker repl accepting client at %s...\n", conn.RemoteAddr())
 
-	runeReader := bufio.NewReader(conn)
+	runeReader := bufio.NewReader(input)
 
 	/* The rest of this code comes from repl(), below: */
 
@@ -245,7 +256,8 @@ func srepl(port string, phase Phase) {
 
 	reader := NewReader(runeReader, "<srepl>")
 
-	fmt.Fprintf(Stdout, "Welcome to joker %s, client at %s. Use '(exit)', or close the connection, to exit.\n",
+	fmt.Fprintf(Stdout, "Welcome to joker %s, client at %s. Use '(exit)', or close the connection, to exit.\n"+
+		"Ctrl-C (sent as a character or by telnet) interrupts evaluation.\n",
 		VERSION, conn.RemoteAddr())
 
 	for {
@@ -303,9 +315,92 @@ func lintFile(filename string, dialect Dialect, workingDir string) {
 	}
 	ReadConfig(filename, workingDir)
 	configureLinterMode(dialect, filename, workingDir)
+	if lintCacheDir != "" && filename != "-" {
+		lintFileWithCache(filename, dialect, workingDir, phase)
+		return
+	}
 	if processFile(filename, phase) == nil {
 		WarnOnUnusedNamespaces()
 		WarnOnUnusedVars()
+		if fixFlag {
+			fixFile(filename)
+		}
+	}
+}
+
+// lintFileWithCache lints filename with the interfaces of the namespaces
+// it requires from the --lint-cache cache, unless its cached results are
+// valid, and caches the results.
+func lintFileWithCache(filename string, dialect Dialect, workingDir string, phase Phase) {
+	cache, err := NewLintCache(lintCacheDir)
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
+	}
+	key := cache.Key(filename, string(src), dialect, workingDir)
+	if entry := cache.Entry(filename); entry != nil && entry.Key == key {
+		fmt.Fprint(Stderr, entry.Output)
+		PROBLEM_COUNT += entry.Problems
+		return
+	}
+	cache.Restore(filename, string(src))
+	var output bytes.Buffer
+	problemCount := PROBLEM_COUNT
+	withStderr(io.MultiWriter(Stderr, &output), func() {
+		if processFile(filename, phase) == nil {
+			WarnOnUnusedNamespaces()
+			WarnOnUnusedVars()
+		}
+	})
+	if err := cache.Store(filename, string(src), key, output.String(), PROBLEM_COUNT-problemCount); err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+	}
+}
+
+// withStderr calls f with the lint warnings, which are printed to both
+// Stderr and *err* (by joker.core macros), written to w instead.
+func withStderr(w io.Writer, f func()) {
+	stderr := Stderr
+	stdin, stdout, errWriter := GLOBAL_ENV.StdIO()
+	Stderr = w
+	GLOBAL_ENV.SetStdIO(stdin, stdout, MakeIOWriter(w))
+	defer func() {
+		Stderr = stderr
+		GLOBAL_ENV.SetStdIO(stdin, stdout, errWriter)
+	}()
+	f()
+}
+
+// fixFile applies the fixes of the warnings found while linting filename
+// and prints what was fixed.
+func fixFile(filename string) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		return
+	}
+	fixed, summary, err := FixLintWarnings(filename, string(src))
+	if err != nil {
+		fmt.Fprintln(Stderr, err)
+		return
+	}
+	if fixed != string(src) {
+		info, err := os.Stat(filename)
+		if err == nil {
+			err = os.WriteFile(filename, []byte(fixed), info.Mode())
+		}
+		if err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+			return
+		}
+	}
+	for _, s := range summary {
+		fmt.Fprintln(Stdout, s)
 	}
 }
 
@@ -334,8 +429,91 @@ func isIgnored(path string) bool {
 	return false
 }
 
-func lintDir(dirname string, dialect Dialect, reportGloballyUnused bool) {
-	var processErr error
+// dialectFiles returns the paths and contents of the files of dialect
+// in dirname that are not ignored.
+func dialectFiles(dirname string, dialect Dialect) (paths []string, srcs []string) {
+	filepath.Walk(dirname, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+			return nil
+		}
+		if !info.IsDir() && matchesDialect(path, dialect) && !isIgnored(path) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintln(Stderr, "Error: ", err)
+				return nil
+			}
+			paths = append(paths, path)
+			srcs = append(srcs, string(src))
+		}
+		return nil
+	})
+	return
+}
+
+// lintRounds groups the files (paths and their contents srcs) in
+// rounds, so that each file comes after the files defining the
+// namespaces it requires (unless they require each other) and the
+// files of a round can be linted in parallel.
+func lintRounds(paths []string, srcs []string) [][]int {
+	// deps[i] are the files defining the namespaces the i-th file requires.
+	deps := make([][]int, len(paths))
+	definedIn := make(map[string][]int)
+	required := make([][]string, len(paths))
+	for i, path := range paths {
+		nsDeps, _ := FileNamespaceDeps(path, srcs[i])
+		for name, names := range nsDeps {
+			definedIn[name] = append(definedIn[name], i)
+			required[i] = append(required[i], names...)
+		}
+	}
+	for i := range paths {
+		for _, name := range required[i] {
+			for _, j := range definedIn[name] {
+				if j != i {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+
+	var res [][]int
+	done := make([]bool, len(paths))
+	for remaining := len(paths); remaining > 0; {
+		var ready []int
+		for i := range paths {
+			if done[i] {
+				continue
+			}
+			isReady := true
+			for _, j := range deps[i] {
+				isReady = isReady && done[j]
+			}
+			if isReady {
+				ready = append(ready, i)
+			}
+		}
+		if len(ready) == 0 {
+			// The rest of the files require each other.
+			for i := range paths {
+				if !done[i] {
+					ready = append(ready, i)
+				}
+			}
+		}
+		for _, i := range ready {
+			done[i] = true
+		}
+		remaining -= len(ready)
+		res = append(res, ready)
+	}
+	return res
+}
+
+// lintFiles lints the files of dialect in dirname, in the order of
+// lintRounds, calling linted with the path of each file and the error
+// (if any) of processing it.
+func lintFiles(dirname string, dialect Dialect, linted func(path string, err error)) {
 	phase := PARSE
 	if dialect == EDN {
 		phase = READ
@@ -343,26 +521,246 @@ func lintDir(dirname string, dialect Dialect, reportGloballyUnused bool) {
 	ns := GLOBAL_ENV.CurrentNamespace()
 	ReadConfig("", dirname)
 	configureLinterMode(dialect, "", dirname)
+	paths, srcs := dialectFiles(dirname, dialect)
+	for _, round := range lintRounds(paths, srcs) {
+		for _, i := range round {
+			GLOBAL_ENV.CoreNamespace.Resolve("*loaded-libs*").Value = EmptySet()
+			linted(paths[i], processFile(paths[i], phase))
+			ResetUsage()
+			GLOBAL_ENV.SetCurrentNamespace(ns)
+		}
+	}
+}
+
+func lintDir(dirname string, dialect Dialect, reportGloballyUnused bool) {
+	var processErr error
+	lintFiles(dirname, dialect, func(path string, err error) {
+		processErr = err
+		if err == nil {
+			WarnOnUnusedNamespaces()
+			WarnOnUnusedVars()
+			if fixFlag {
+				fixFile(path)
+			}
+		}
+	})
+	if processErr == nil && reportGloballyUnused {
+		WarnOnGloballyUnusedNamespaces()
+		WarnOnGloballyUnusedVars()
+	}
+}
+
+// lintDirInParallel lints the files of dialect in dirname as lintDir does,
+// but in up to lintJobs processes at a time, each linting a file with
+// the --lint-cache cache (or a temporary one), and only the files whose
+// cached results are not valid. The files are linted in the rounds of
+// lintRounds, so that they use the interfaces of the namespaces they
+// require as linted.
+func lintDirInParallel(dirname string, dialect Dialect, reportGloballyUnused bool) {
+	cacheDir := lintCacheDir
+	if cacheDir == "" {
+		dir, err := os.MkdirTemp("", "joker-lint-cache-")
+		if err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+			ExitJoker(1)
+		}
+		defer os.RemoveAll(dir)
+		cacheDir = dir
+	}
+	cache, err := NewLintCache(cacheDir)
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
+	}
+	jobs := lintJobs
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
+	}
+	ReadConfig("", dirname)
+	paths, srcs := dialectFiles(dirname, dialect)
+	rounds := lintRounds(paths, srcs)
+
+	entries := make([]*LintCacheEntry, len(paths))
+	failures := make([]string, len(paths))
+	for _, round := range rounds {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, jobs)
+		for _, i := range round {
+			key := cache.Key(paths[i], srcs[i], dialect, dirname)
+			if entry := cache.Entry(paths[i]); entry != nil && entry.Key == key {
+				entries[i] = entry
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, key string) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				cmd := exec.Command(exe, "--lint", "--dialect", dialectArg(dialect), "--working-dir", dirname, "--lint-cache", cacheDir, paths[i])
+				var stderr bytes.Buffer
+				cmd.Stderr = &stderr
+				cmd.Run()
+				if entry := cache.Entry(paths[i]); entry != nil && entry.Key == key {
+					entries[i] = entry
+				} else {
+					failures[i] = stderr.String()
+				}
+			}(i, key)
+		}
+		wg.Wait()
+	}
+
+	// The results are printed in the order lintDir prints them.
+	for _, round := range rounds {
+		for _, i := range round {
+			if entries[i] != nil {
+				fmt.Fprint(Stderr, entries[i].Output)
+				PROBLEM_COUNT += entries[i].Problems
+			} else {
+				fmt.Fprintf(Stderr, "Error: Cannot lint %s\n%s", paths[i], failures[i])
+				PROBLEM_COUNT++
+			}
+		}
+	}
+	if reportGloballyUnused {
+		WarnOnGloballyUnusedInEntries(paths, entries)
+	}
+}
+
+// findReferences prints the definitions of and references to the var
+// named target in the files of dialect in dirname or, if newName is not
+// empty, renames the var to newName in place, printing what was renamed.
+func findReferences(target string, newName string, dirname string, dialect Dialect) {
+	sym := MakeSymbol(target)
+	if sym.Namespace() == "" {
+		fmt.Fprintf(Stderr, "Error: %s is not a namespace-qualified symbol.\n", target)
+		ExitJoker(23)
+	}
+	SetReferencesTarget(sym)
+	var paths []string
+	// Only the references are of interest, not lint warnings.
+	stderr := Stderr
+	withStderr(io.Discard, func() {
+		lintFiles(dirname, dialect, func(path string, err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: Cannot read %s: %v\n", path, err)
+				return
+			}
+			paths = append(paths, path)
+		})
+	})
+	// The files are linted in the order of their dependencies.
+	sort.Strings(paths)
+	if newName != "" {
+		if err := CheckRenameTarget(newName); err != nil {
+			fmt.Fprintln(Stderr, "Error:", err)
+			ExitJoker(1)
+		}
+	}
+	found := false
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+			continue
+		}
+		var lines []string
+		if newName == "" {
+			lines, err = FindReferences(path, string(src))
+		} else {
+			var renamed string
+			renamed, lines, err = RenameReferences(path, string(src), newName)
+			if err == nil && renamed != string(src) {
+				var info os.FileInfo
+				if info, err = os.Stat(path); err == nil {
+					err = os.WriteFile(path, []byte(renamed), info.Mode())
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+			continue
+		}
+		for _, line := range lines {
+			fmt.Fprintln(Stdout, line)
+		}
+		found = found || len(lines) > 0
+	}
+	if !found {
+		fmt.Fprintf(Stderr, "No references to %s found.\n", target)
+		ExitJoker(1)
+	}
+}
+
+// printDepsGraph prints the graph of the namespaces defined in the files
+// of dialect in dirname and the namespaces they require in format, then
+// reports the cycles in it.
+func printDepsGraph(dirname string, dialect Dialect, format string) {
+	ReadConfig("", dirname)
+	graph := NewDepsGraph()
 	filepath.Walk(dirname, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			fmt.Fprintln(Stderr, "Error: ", err)
 			return nil
 		}
 		if !info.IsDir() && matchesDialect(path, dialect) && !isIgnored(path) {
-			GLOBAL_ENV.CoreNamespace.Resolve("*loaded-libs*").Value = EmptySet()
-			processErr = processFile(path, phase)
-			if processErr == nil {
-				WarnOnUnusedNamespaces()
-				WarnOnUnusedVars()
+			src, err := os.ReadFile(path)
+			if err == nil {
+				err = graph.AddFile(path, string(src))
+			}
+			if err != nil {
+				fmt.Fprintln(Stderr, "Error: ", err)
 			}
-			ResetUsage()
-			GLOBAL_ENV.SetCurrentNamespace(ns)
 		}
 		return nil
 	})
-	if processErr == nil && reportGloballyUnused {
-		WarnOnGloballyUnusedNamespaces()
-		WarnOnGloballyUnusedVars()
+	switch format {
+	case "edn":
+		printOutput(graph.Map(), format)
+	default:
+		fmt.Fprintln(Stdout, graph.DOT())
+	}
+	cycles := graph.Cycles()
+	for _, cycle := range cycles {
+		fmt.Fprintf(Stderr, "Cyclic dependency: %s\n", strings.Join(cycle, " -> "))
+	}
+	if len(cycles) > 0 {
+		ExitJoker(1)
+	}
+}
+
+// generateDocs writes the API docs of the namespaces defined in the files
+// of dialect in dirname to outDir.
+func generateDocs(dirname string, dialect Dialect, outDir string) {
+	StartDocCollection()
+	var paths []string
+	// Only the metadata is of interest, not lint warnings.
+	stderr := Stderr
+	withStderr(io.Discard, func() {
+		lintFiles(dirname, dialect, func(path string, err error) {
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: Cannot read %s: %v\n", path, err)
+				return
+			}
+			paths = append(paths, path)
+		})
+	})
+	err := GenerateDocs(outDir, paths, DocOptions{
+		Format:    docFormat,
+		Private:   docPrivate,
+		SrcDir:    dirname,
+		SourceURL: docSourceURL,
+	})
+	if err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
 	}
 }
 
@@ -380,6 +778,18 @@ func dialectFromArg(arg string) Dialect {
 	return UNKNOWN
 }
 
+func dialectArg(dialect Dialect) string {
+	switch dialect {
+	case CLJS:
+		return "cljs"
+	case JOKER:
+		return "joker"
+	case EDN:
+		return "edn"
+	}
+	return "clj"
+}
+
 func usage(out io.Writer) {
 	fmt.Fprintf(out, "Joker - %s\n\n", VERSION)
 	fmt.Fprintln(out, "Usage: joker [args] [-- <repl-args>]                starts a repl")
@@ -404,6 +814,9 @@ func usage(out io.Writer) {
 	fmt.Fprintln(out, "    Print version number and exit.")
 	fmt.Fprintln(out, "  --read")
 	fmt.Fprintln(out, "    Read, but do not parse nor evaluate, the input.")
+	fmt.Fprintln(out, "  --dump <format>")
+	fmt.Fprintln(out, "    With --read or --parse, print each form (read in format mode, so including comments)")
+	fmt.Fprintln(out, "    or parsed expression, with positions, as EDN (\"edn\") or JSON (\"json\") data.")
 	fmt.Fprintln(out, "  --format")
 	fmt.Fprintln(out, "    Format the source code and print it to standard output.")
 	fmt.Fprintln(out, "  --write")
@@ -422,6 +835,40 @@ func usage(out io.Writer) {
 	fmt.Fprintln(out, "    Do not read or save repl command history to a file.")
 	fmt.Fprintln(out, "  --working-dir <directory>")
 	fmt.Fprintln(out, "    Specify directory to lint or working directory for lint configuration if linting single file (requires --lint).")
+	fmt.Fprintln(out, "  --fix")
+	fmt.Fprintln(out, "    Fix warnings that have safe, mechanical fixes (redundant do forms, unused bindings")
+	fmt.Fprintln(out, "    and namespaces) and sort :require clauses in place, printing what was fixed (requires --lint).")
+	fmt.Fprintln(out, "  --lint-cache <dir>")
+	fmt.Fprintln(out, "    Cache lint results in <dir>, so that linting a directory again only lints the files that")
+	fmt.Fprintln(out, "    changed and the files requiring namespaces whose vars changed (requires --lint).")
+	fmt.Fprintln(out, "  --jobs <n>")
+	fmt.Fprintln(out, "    Lint up to <n> files of a directory in parallel (default: the number of CPUs),")
+	fmt.Fprintln(out, "    using --lint-cache (or a temporary cache) to share what they define.")
+	fmt.Fprintln(out, "  --refs <ns/var>")
+	fmt.Fprintln(out, "    Print the definitions of and references to the var in the files (of --dialect) in <filename>,")
+	fmt.Fprintln(out, "    a directory, including :refer lists.")
+	fmt.Fprintln(out, "  --rename <ns/var> <new-name>")
+	fmt.Fprintln(out, "    Rename the var in the files (of --dialect) in <filename>, a directory: its definitions,")
+	fmt.Fprintln(out, "    qualified and aliased uses, and :refer lists. Files are changed in place.")
+	fmt.Fprintln(out, "  --deps-graph <dir>")
+	fmt.Fprintln(out, "    Print the graph of the namespaces defined in the files (of --dialect) in <dir> and the")
+	fmt.Fprintln(out, "    namespaces they require, reporting dependency cycles (which make the exit code non-zero).")
+	fmt.Fprintln(out, "  --deps-format <format>")
+	fmt.Fprintln(out, "    Print the --deps-graph graph in the DOT language of Graphviz (\"dot\", the default) or as")
+	fmt.Fprintln(out, "    an EDN map from namespaces to the namespaces they require (\"edn\").")
+	fmt.Fprintln(out, "  --doc <dir>")
+	fmt.Fprintln(out, "    Write the API docs of the namespaces defined in the files (of --dialect) in <dir> to the")
+	fmt.Fprintln(out, "    -o/--output directory, from the :doc, :arglists, :added and :deprecated metadata of their vars.")
+	fmt.Fprintln(out, "  -o, --output <dir>")
+	fmt.Fprintln(out, "    Write the --doc API docs to <dir>.")
+	fmt.Fprintln(out, "  --doc-format <format>")
+	fmt.Fprintln(out, "    Write the --doc API docs as HTML pages with a search index (\"html\", the default)")
+	fmt.Fprintln(out, "    or as Markdown files (\"markdown\").")
+	fmt.Fprintln(out, "  --doc-private")
+	fmt.Fprintln(out, "    Include private vars in the --doc API docs.")
+	fmt.Fprintln(out, "  --doc-source-url <url>")
+	fmt.Fprintln(out, "    Link the vars in the --doc API docs to <url>, with {path} replaced by the path of their file")
+	fmt.Fprintln(out, "    (relative to the --doc directory) and {line} by their line (default: links to the files).")
 	fmt.Fprintln(out, "  --report-globally-unused")
 	fmt.Fprintln(out, "    Report globally unused namespaces and public vars when linting directories (requires --lint and --working-dir).")
 	fmt.Fprintln(out, "  --dialect <dialect>")
@@ -429,6 +876,26 @@ func usage(out io.Writer) {
 	fmt.Fprintln(out, "    default is inferred from <filename> suffix, if any.")
 	fmt.Fprintln(out, "  --hashmap-threshold <n>")
 	fmt.Fprintln(out, "    Set HASHMAP_THRESHOLD accordingly (internal magic of some sort).")
+	fmt.Fprintln(out, "  --in <format>")
+	fmt.Fprintln(out, "    Bind *input* to a lazy sequence of the values read from stdin: forms (\"edn\"),")
+	fmt.Fprintln(out, "    JSON values with keyword keys (\"json\"), lines (\"lines\"), or records (\"csv\").")
+	fmt.Fprintln(out, "  --out <format>")
+	fmt.Fprintln(out, "    Print only the result of the last --eval expression, as EDN (\"edn\"), JSON (\"json\"),")
+	fmt.Fprintln(out, "    or one line per element (\"lines\").")
+	fmt.Fprintln(out, "  --compile")
+	fmt.Fprintln(out, "    Compile code to Go closures before evaluating it (faster for compute-heavy code).")
+	fmt.Fprintln(out, "  --sandbox")
+	fmt.Fprintln(out, "    Only allow code to use namespaces and vars that don't access files, processes, or the network.")
+	fmt.Fprintln(out, "  --sandbox-allow <names>")
+	fmt.Fprintln(out, "    Also allow the comma-separated namespaces and fully qualified vars in sandbox mode (implies --sandbox).")
+	fmt.Fprintln(out, "  --timeout <duration>")
+	fmt.Fprintln(out, "    Abort evaluation after <duration> (e.g. 500ms, 10s); in the repl, applies to each form.")
+	fmt.Fprintln(out, "  --max-steps <n>")
+	fmt.Fprintln(out, "    Abort evaluation after evaluating <n> expressions; in the repl, applies to each form.")
+	fmt.Fprintln(out, "  --max-stack-depth, --max-call-depth <n>")
+	fmt.Fprintf(out, "    Fail calls nested more than <n> levels deep with a StackOverflowError (default %d; 0 for no limit).\n", DefaultMaxCallDepth)
+	fmt.Fprintln(out, "  --max-alloc <bytes>")
+	fmt.Fprintln(out, "    Abort evaluation after allocating approximately <bytes> bytes; in the repl, applies to each form.")
 	fmt.Fprintln(out, "  --profiler <type>")
 	fmt.Fprintln(out, "    Specify type of profiler to use (default 'runtime/pprof' or 'pkg/profile').")
 	fmt.Fprintln(out, "  --cpuprofile <name>")
@@ -451,8 +918,23 @@ var (
 	workingDir               string
 	lintFlag                 bool
 	reportGloballyUnusedFlag bool
+	fixFlag                  bool
+	lintCacheDir             string
+	lintJobs                 int
+	refsTarget               string
+	renameTo                 string
+	depsGraphDir             string
+	depsFormat               string = "dot"
+	docDir                   string
+	docOutDir                string
+	docFormat                string
+	docPrivate               bool
+	docSourceURL             string
 	dialect                  Dialect = UNKNOWN
 	eval                     string
+	inFormat                 string
+	outFormat                string
+	dumpFormat               string
 	replFlag                 bool
 	replSocket               string
 	classPath                string
@@ -468,6 +950,11 @@ var (
 	exitToRepl               bool
 	errorToRepl              bool
 	writeFlag                bool
+	compileFlag              bool
+	sandboxFlag              bool
+	sandboxAllow             []string
+	evalLimits               EvalLimits
+	maxStackDepth            = DefaultMaxCallDepth
 )
 
 func isNumber(s string) bool {
@@ -557,6 +1044,91 @@ func parseArgs(args []string) {
 			}
 		case "--report-globally-unused":
 			reportGloballyUnusedFlag = true
+		case "--fix":
+			fixFlag = true
+		case "--lint-cache":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				lintCacheDir = args[i]
+			} else {
+				missing = true
+			}
+		case "--jobs":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				n, err := strconv.ParseUint(args[i], 10, 31)
+				if err == nil && n == 0 {
+					err = fmt.Errorf("--jobs must be positive")
+				}
+				if err != nil {
+					fmt.Fprintln(Stderr, "Error: ", err)
+					ExitJoker(2)
+				}
+				lintJobs = int(n)
+			} else {
+				missing = true
+			}
+		case "--refs":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				refsTarget = args[i]
+			} else {
+				missing = true
+			}
+		case "--rename":
+			if i < length-2 && notOption(args[i+1]) && notOption(args[i+2]) {
+				refsTarget = args[i+1]
+				renameTo = args[i+2]
+				i += 2 // shift
+			} else {
+				missing = true
+			}
+		case "--deps-graph":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				depsGraphDir = args[i]
+			} else {
+				missing = true
+			}
+		case "--deps-format":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				checkDataFormat("--deps-format", args[i], depsFormats)
+				depsFormat = args[i]
+			} else {
+				missing = true
+			}
+		case "--doc":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				docDir = args[i]
+			} else {
+				missing = true
+			}
+		case "-o", "--output":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				docOutDir = args[i]
+			} else {
+				missing = true
+			}
+		case "--doc-format":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				checkDataFormat("--doc-format", args[i], docFormats)
+				docFormat = args[i]
+			} else {
+				missing = true
+			}
+		case "--doc-private":
+			docPrivate = true
+		case "--doc-source-url":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				docSourceURL = args[i]
+			} else {
+				missing = true
+			}
 		case "--lint":
 			lintFlag = true
 		case "--lintclj":
@@ -636,6 +1208,76 @@ func parseArgs(args []string) {
 				i += 1 // shift
 				filename = args[i]
 			}
+		case "--in", "--out", "--dump":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				switch args[i-1] {
+				case "--in":
+					checkDataFormat("--in", args[i], inFormats)
+					inFormat = args[i]
+				case "--out":
+					checkDataFormat("--out", args[i], outFormats)
+					outFormat = args[i]
+				default:
+					checkDataFormat("--dump", args[i], dumpFormats)
+					dumpFormat = args[i]
+				}
+			} else {
+				missing = true
+			}
+		case "--compile":
+			compileFlag = true
+		case "--sandbox":
+			sandboxFlag = true
+		case "--sandbox-allow":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				sandboxFlag = true
+				sandboxAllow = append(sandboxAllow, strings.Split(args[i], ",")...)
+			} else {
+				missing = true
+			}
+		case "--timeout":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				timeout, err := time.ParseDuration(args[i])
+				if err != nil {
+					fmt.Fprintln(Stderr, "Error: ", err)
+					ExitJoker(2)
+				}
+				evalLimits.Timeout = timeout
+			} else {
+				missing = true
+			}
+		case "--max-steps", "--max-alloc":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				n, err := strconv.ParseUint(args[i], 10, 64)
+				if err != nil {
+					fmt.Fprintln(Stderr, "Error: ", err)
+					ExitJoker(2)
+				}
+				switch args[i-1] {
+				case "--max-steps":
+					evalLimits.MaxSteps = int(n)
+				default:
+					evalLimits.MaxAlloc = n
+				}
+			} else {
+				missing = true
+			}
+		case "--max-stack-depth", "--max-call-depth":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				n, err := strconv.ParseUint(args[i], 10, 31)
+				if err != nil {
+					fmt.Fprintln(Stderr, "Error: ", err)
+					ExitJoker(2)
+				}
+				maxStackDepth = int(n)
+			} else {
+				missing = true
+			}
 		case "--profiler":
 			if i < length-1 && notOption(args[i+1]) {
 				i += 1 // shift
@@ -729,12 +1371,20 @@ func main() {
 
 	saveForRepl = saveForRepl && (exitToRepl || errorToRepl) // don't bother saving stuff if no repl
 
-	RT.GIL.Lock()
+	// The main goroutine runs code in the default interpreter.
+	DefaultInterpreter().Enter()
+	SetMaxCallDepth(maxStackDepth)
+	SetCompilation(compileFlag)
 	ProcessCoreData()
 
 	GLOBAL_ENV.ReferCoreToUser()
 	GLOBAL_ENV.SetEnvArgs(remainingArgs)
 	GLOBAL_ENV.SetClassPath(classPath)
+	if sandboxFlag {
+		sb := NewSandbox()
+		sb.Allow(sandboxAllow...)
+		GLOBAL_ENV.SetSandbox(sb)
+	}
 
 	if debugOut != nil {
 		fmt.Fprintf(debugOut, "debugOut=%v\n", debugOut)
@@ -743,10 +1393,25 @@ func main() {
 		fmt.Fprintf(debugOut, "phase=%v\n", phase)
 		fmt.Fprintf(debugOut, "lintFlag=%v\n", lintFlag)
 		fmt.Fprintf(debugOut, "reportGloballyUnusedFlag=%v\n", reportGloballyUnusedFlag)
+		fmt.Fprintf(debugOut, "fixFlag=%v\n", fixFlag)
+		fmt.Fprintf(debugOut, "lintCacheDir=%v\n", lintCacheDir)
+		fmt.Fprintf(debugOut, "lintJobs=%v\n", lintJobs)
+		fmt.Fprintf(debugOut, "refsTarget=%v\n", refsTarget)
+		fmt.Fprintf(debugOut, "renameTo=%v\n", renameTo)
+		fmt.Fprintf(debugOut, "depsGraphDir=%v\n", depsGraphDir)
+		fmt.Fprintf(debugOut, "depsFormat=%v\n", depsFormat)
+		fmt.Fprintf(debugOut, "docDir=%v\n", docDir)
+		fmt.Fprintf(debugOut, "docOutDir=%v\n", docOutDir)
+		fmt.Fprintf(debugOut, "docFormat=%v\n", docFormat)
+		fmt.Fprintf(debugOut, "docPrivate=%v\n", docPrivate)
+		fmt.Fprintf(debugOut, "docSourceURL=%v\n", docSourceURL)
 		fmt.Fprintf(debugOut, "dialect=%v\n", dialect)
 		fmt.Fprintf(debugOut, "workingDir=%v\n", workingDir)
 		fmt.Fprintf(debugOut, "HASHMAP_THRESHOLD=%v\n", HASHMAP_THRESHOLD)
 		fmt.Fprintf(debugOut, "eval=%v\n", eval)
+		fmt.Fprintf(debugOut, "inFormat=%v\n", inFormat)
+		fmt.Fprintf(debugOut, "outFormat=%v\n", outFormat)
+		fmt.Fprintf(debugOut, "dumpFormat=%v\n", dumpFormat)
 		fmt.Fprintf(debugOut, "replFlag=%v\n", replFlag)
 		fmt.Fprintf(debugOut, "replSocket=%v\n", replSocket)
 		fmt.Fprintf(debugOut, "classPath=%v\n", classPath)
@@ -757,6 +1422,11 @@ func main() {
 		fmt.Fprintf(debugOut, "exitToRepl=%v\n", exitToRepl)
 		fmt.Fprintf(debugOut, "errorToRepl=%v\n", errorToRepl)
 		fmt.Fprintf(debugOut, "saveForRepl=%v\n", saveForRepl)
+		fmt.Fprintf(debugOut, "compileFlag=%v\n", compileFlag)
+		fmt.Fprintf(debugOut, "sandboxFlag=%v\n", sandboxFlag)
+		fmt.Fprintf(debugOut, "sandboxAllow=%v\n", sandboxAllow)
+		fmt.Fprintf(debugOut, "evalLimits=%+v\n", evalLimits)
+		fmt.Fprintf(debugOut, "maxStackDepth=%v\n", maxStackDepth)
 	}
 
 	if helpFlag {
@@ -812,6 +1482,48 @@ func main() {
 		defer finish()
 	}
 
+	if outFormat != "" && eval == "" {
+		fmt.Fprintf(Stderr, "Error: --out requires --eval/-e.\n")
+		ExitJoker(18)
+	}
+
+	if fixFlag && !lintFlag {
+		fmt.Fprintf(Stderr, "Error: --fix requires --lint.\n")
+		ExitJoker(20)
+	}
+
+	if (lintCacheDir != "" || lintJobs != 0) && !lintFlag {
+		fmt.Fprintf(Stderr, "Error: --lint-cache and --jobs require --lint.\n")
+		ExitJoker(24)
+	}
+
+	if fixFlag && (lintCacheDir != "" || lintJobs != 0) {
+		fmt.Fprintf(Stderr, "Error: Cannot combine --fix and --lint-cache or --jobs.\n")
+		ExitJoker(25)
+	}
+
+	if docDir != "" && docOutDir == "" {
+		fmt.Fprintf(Stderr, "Error: --doc requires -o/--output.\n")
+		ExitJoker(26)
+	}
+
+	if docDir == "" && (docOutDir != "" || docFormat != "" || docPrivate || docSourceURL != "") {
+		fmt.Fprintf(Stderr, "Error: -o/--output, --doc-format, --doc-private and --doc-source-url require --doc.\n")
+		ExitJoker(27)
+	}
+
+	if dumpFormat != "" && phase != READ && phase != PARSE {
+		fmt.Fprintf(Stderr, "Error: --dump requires --read or --parse.\n")
+		ExitJoker(19)
+	}
+
+	if !lintFlag {
+		if inFormat != "" {
+			bindInput(inFormat)
+		}
+		SetEvalLimits(evalLimits)
+	}
+
 	if eval != "" {
 		if lintFlag {
 			fmt.Fprintf(Stderr, "Error: Cannot combine --eval/-e and --lint.\n")
@@ -837,7 +1549,18 @@ func main() {
 		if saveForRepl {
 			reader = NewReader(&replayable{reader}, "<replay>")
 		}
-		if err := ProcessReader(reader, "", phase); err != nil {
+		var err error
+		if outFormat != "" && phase == PRINT_IF_NOT_NIL {
+			var res Object
+			if res, err = EvalReader(reader, ""); err == nil {
+				err = printOutput(res, outFormat)
+			}
+		} else if dumpFormat != "" {
+			err = DumpReader(reader, "", phase, printDump)
+		} else {
+			err = ProcessReader(reader, "", phase)
+		}
+		if err != nil {
 			if !errorToRepl {
 				ExitJoker(1)
 			}
@@ -848,6 +1571,34 @@ func main() {
 		}
 	}
 
+	if depsGraphDir != "" {
+		if dialect == UNKNOWN {
+			dialect = detectDialect(depsGraphDir)
+		}
+		printDepsGraph(depsGraphDir, dialect, depsFormat)
+		return
+	}
+
+	if docDir != "" {
+		if dialect == UNKNOWN {
+			dialect = detectDialect(docDir)
+		}
+		generateDocs(docDir, dialect, docOutDir)
+		return
+	}
+
+	if refsTarget != "" {
+		if filename == "" || filename == "-" {
+			fmt.Fprintf(Stderr, "Error: --refs and --rename require a directory (<filename>) argument.\n")
+			ExitJoker(22)
+		}
+		if dialect == UNKNOWN {
+			dialect = detectDialect(filename)
+		}
+		findReferences(refsTarget, renameTo, filename, dialect)
+		return
+	}
+
 	if lintFlag {
 		if replFlag {
 			fmt.Fprintf(Stderr, "Error: Cannot combine --lint and --repl.\n")
@@ -864,8 +1615,15 @@ func main() {
 		if dialect == UNKNOWN {
 			dialect = detectDialect(filename)
 		}
+		if fixFlag && filename == "-" {
+			fmt.Fprintf(Stderr, "Error: Cannot fix standard input.\n")
+			ExitJoker(21)
+		}
+		LINT_FIX_MODE = fixFlag
 		if filename != "" {
 			lintFile(filename, dialect, workingDir)
+		} else if workingDir != "" && (lintCacheDir != "" || lintJobs != 0) {
+			lintDirInParallel(workingDir, dialect, reportGloballyUnusedFlag)
 		} else if workingDir != "" {
 			lintDir(workingDir, dialect, reportGloballyUnusedFlag)
 		} else {
@@ -884,6 +1642,9 @@ func main() {
 	}
 
 	if filename != "" {
+		if phase == FORMAT {
+			ReadConfig(filename, "")
+		}
 		if err := processFile(filename, phase); err != nil {
 			if !errorToRepl {
 				ExitJoker(1)
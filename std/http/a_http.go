@@ -6,6 +6,115 @@ import (
 	. "github.com/candid82/joker/core"
 )
 
+var __connect__P ProcFn = __connect_
+var connect_ Proc = Proc{Fn: __connect__P, Name: "connect_", Package: "std/http"}
+
+func __connect_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		url := ExtractString(_args, 0)
+		_res := connect(url, EmptyArrayMap())
+		return _res
+
+	case _c == 2:
+		url := ExtractString(_args, 0)
+		opts := ExtractMap(_args, 1)
+		_res := connect(url, opts)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __invoke__P ProcFn = __invoke_
+var invoke_ Proc = Proc{Fn: __invoke__P, Name: "invoke_", Package: "std/http"}
+
+func __invoke_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 2:
+		handler := ExtractCallable(_args, 0)
+		request := ExtractMap(_args, 1)
+		_res := invoke(handler, request)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __make_client__P ProcFn = __make_client_
+var make_client_ Proc = Proc{Fn: __make_client__P, Name: "make_client_", Package: "std/http"}
+
+func __make_client_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		opts := ExtractMap(_args, 0)
+		_res := makeClient(opts)
+		return MakeHttpClient(_res)
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __mock_request__P ProcFn = __mock_request_
+var mock_request_ Proc = Proc{Fn: __mock_request__P, Name: "mock_request_", Package: "std/http"}
+
+func __mock_request_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		request := ExtractMap(_args, 0)
+		_res := mockRequest(request)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __mock_requests__P ProcFn = __mock_requests_
+var mock_requests_ Proc = Proc{Fn: __mock_requests__P, Name: "mock_requests_", Package: "std/http"}
+
+func __mock_requests_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		addr := ExtractString(_args, 0)
+		_res := mockRequests(addr)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __router__P ProcFn = __router_
+var router_ Proc = Proc{Fn: __router__P, Name: "router_", Package: "std/http"}
+
+func __router_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		routes := ExtractSeqable(_args, 0)
+		_res := router(routes)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
 var __send__P ProcFn = __send_
 var send_ Proc = Proc{Fn: __send__P, Name: "send_", Package: "std/http"}
 
@@ -14,7 +123,13 @@ func __send_(_args []Object) Object {
 	switch {
 	case _c == 1:
 		request := ExtractMap(_args, 0)
-		_res := sendRequest(request)
+		_res := sendRequest(defaultClient, request)
+		return _res
+
+	case _c == 2:
+		client := ExtractHttpClient(_args, 0)
+		request := ExtractMap(_args, 1)
+		_res := sendRequest(client, request)
 		return _res
 
 	default:
@@ -41,6 +156,23 @@ func __start_file_server_(_args []Object) Object {
 	return NIL
 }
 
+var __start_mock_server__P ProcFn = __start_mock_server_
+var start_mock_server_ Proc = Proc{Fn: __start_mock_server__P, Name: "start_mock_server_", Package: "std/http"}
+
+func __start_mock_server_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		responses := ExtractObject(_args, 0)
+		_res := startMockServer(responses)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
 var __start_server__P ProcFn = __start_server_
 var start_server_ Proc = Proc{Fn: __start_server__P, Name: "start_server_", Package: "std/http"}
 
@@ -50,7 +182,55 @@ func __start_server_(_args []Object) Object {
 	case _c == 2:
 		addr := ExtractString(_args, 0)
 		handler := ExtractCallable(_args, 1)
-		_res := startServer(addr, handler)
+		_res := startServer(addr, handler, EmptyArrayMap())
+		return _res
+
+	case _c == 3:
+		addr := ExtractString(_args, 0)
+		handler := ExtractCallable(_args, 1)
+		opts := ExtractMap(_args, 2)
+		_res := startServer(addr, handler, opts)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __stop_server__P ProcFn = __stop_server_
+var stop_server_ Proc = Proc{Fn: __stop_server__P, Name: "stop_server_", Package: "std/http"}
+
+func __stop_server_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		addr := ExtractString(_args, 0)
+		_res := stopServer(addr, 5000)
+		return _res
+
+	case _c == 2:
+		addr := ExtractString(_args, 0)
+		timeout := ExtractInt(_args, 1)
+		_res := stopServer(addr, timeout)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __wrap_middleware__P ProcFn = __wrap_middleware_
+var wrap_middleware_ Proc = Proc{Fn: __wrap_middleware__P, Name: "wrap_middleware_", Package: "std/http"}
+
+func __wrap_middleware_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 2:
+		handler := ExtractCallable(_args, 0)
+		middleware := ExtractSeqable(_args, 1)
+		_res := wrapMiddleware(handler, middleware)
 		return _res
 
 	default:
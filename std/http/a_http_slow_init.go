@@ -14,22 +14,111 @@ func InternsOrThunks() {
 	}
 	httpNamespace.ResetMeta(MakeMeta(nil, `Provides HTTP client and server implementations.`, "1.0"))
 
-	httpNamespace.InternVar("send", send_,
+	httpNamespace.InternVar("connect", connect_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("url")), NewVectorFrom(MakeSymbol("url"), MakeSymbol("opts"))),
+			`Opens a WebSocket connection to url (ws:// or wss://) and returns
+  connection map with the following keys:
+  - in (channel, receives incoming messages as strings;
+    closed when the connection is closed)
+  - out (channel, values put on it are sent as text messages,
+    non-string values are converted as if by str;
+    closing it closes the connection).
+  Optional opts map may have the following keys:
+  - origin (string, defaults to "http://localhost/")
+  - headers (map).`, "1.4"))
+
+	httpNamespace.InternVar("invoke", invoke_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("handler"), MakeSymbol("request"))),
+			`Invokes handler in-process with the request map built from request
+  (see mock-request) and returns the response map as returned by send.
+  The response goes through the same conversion as responses of handlers
+  passed to start-server, so invoke can be used to test handlers
+  without starting a server.`, "1.4"))
+
+	httpNamespace.InternVar("make-client", make_client_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("opts"))),
+			`Returns a new HTTP client with its own connection pool,
+  to be passed to send.
+  opts is a map with the following optional keys:
+  - timeout (int, milliseconds)
+  - follow-redirects (boolean, defaults to true)
+  - max-redirects (int, defaults to 10)
+  - proxy (string, proxy URL)
+  - cookies? (boolean, if true, the client stores cookies it receives
+    and sends them in subsequent requests)
+  - max-idle-conns-per-host (int)
+  - insecure? (boolean, if true, server certificates are not verified)
+  - ca-cert-file (string, path to PEM file with trusted CA certificates)
+  - client-cert-file, client-key-file (string, paths to PEM files
+    with client certificate and key).`, "1.4").Plus(MakeKeyword("tag"), String{S: "HttpClient"}))
+
+	httpNamespace.InternVar("mock-request", mock_request_,
 		MakeMeta(
 			NewListFrom(NewVectorFrom(MakeSymbol("request"))),
+			`Returns a request map, as passed to handlers by start-server,
+  built from request without sending it over the network.
+  request is a map with the same keys as in send. url may be just a path
+  (e.g. "/users/1"), in which case host localhost is assumed.`, "1.4"))
+
+	httpNamespace.InternVar("mock-requests", mock_requests_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("addr"))),
+			`Returns a vector of request maps received so far by the mock server
+  started on addr with start-mock-server.`, "1.4"))
+
+	httpNamespace.InternVar("router", router_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("routes"))),
+			`Returns a handler that dispatches requests to handlers according to routes.
+  routes is a seq of [method path handler] vectors, where method is a keyword,
+  string or symbol (:any matches any method) and path is a pattern
+  such as "/users/:id/files/*path". Segments starting with : match a single
+  path segment, a segment starting with * matches the rest of the path.
+  Matched segments are added to the request map under :path-params key
+  (a map from keywords to strings). Routes are tried in order.
+  Returns 404 response if no route matches the path and 405 response
+  if no route for the path matches the request method.`, "1.4"))
+
+	httpNamespace.InternVar("send", send_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("request")), NewVectorFrom(MakeSymbol("client"), MakeSymbol("request"))),
 			`Sends an HTTP request and returns an HTTP response.
+  If client is not provided, the default client is used.
   request is a map with the following keys:
   - url (string)
   - method (string, keyword or symbol, defaults to :get)
   - body (string)
+  - form-params (map, sent as application/x-www-form-urlencoded body
+    if body is not provided)
+  - multipart (seq of maps with :name and either :content (string)
+    or :file (path) keys, and optional :filename and :content-type keys;
+    sent as multipart/form-data body if neither body nor form-params
+    is provided)
+  - query-params (map, added to the query string of url)
   - host (string, overrides Host header if provided)
-  - headers (map).
+  - headers (map)
+  - basic-auth ([user password] vector or {:user user :password password} map)
+  - cookies (map from cookie names to values)
+  - timeout (int, milliseconds)
+  - follow-redirects (boolean, defaults to true)
+  - max-redirects (int, defaults to 10).
+  When the default client is used, request may also have
+  any of the client options described in make-client. Requests
+  with the same client options share a client (and its connections
+  and cookies).
   All keys except for url are optional.
   response is a map with the following keys:
   - status (int)
   - body (string)
   - headers (map)
-  - content-length (int)`, "1.0"))
+  - content-length (int)
+  - cookies (map from cookie names to values)
+  - url (string, the final URL after following redirects)
+  - redirects (vector of URLs of the redirected requests, in order)
+  - request-time (int, milliseconds)`, "1.0"))
 
 	httpNamespace.InternVar("start-file-server", start_file_server_,
 		MakeMeta(
@@ -37,9 +126,52 @@ func InternsOrThunks() {
 			`Starts HTTP server on the TCP network address addr that
   serves HTTP requests with the contents of the file system rooted at root.`, "1.0"))
 
+	httpNamespace.InternVar("start-mock-server", start_mock_server_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("responses"))),
+			`Starts HTTP server on a random local port that records received requests
+  and returns {:addr addr :url url} map. responses is either a response map
+  (returned for every request), a vector of response maps (returned
+  in order, the last one is repeated for the remaining requests)
+  or a handler function. Use mock-requests to get the recorded requests
+  and stop-server to stop the server, which discards them.`, "1.4"))
+
 	httpNamespace.InternVar("start-server", start_server_,
 		MakeMeta(
-			NewListFrom(NewVectorFrom(MakeSymbol("addr"), MakeSymbol("handler"))),
-			`Starts HTTP server on the TCP network address addr.`, "1.0"))
+			NewListFrom(NewVectorFrom(MakeSymbol("addr"), MakeSymbol("handler")), NewVectorFrom(MakeSymbol("addr"), MakeSymbol("handler"), MakeSymbol("opts"))),
+			`Starts HTTP server on the TCP network address addr.
+  Blocks until the server is stopped with stop-server.
+  handler is called with a request map and must return a response map.
+  If the response map has :websocket key, its value must be a function
+  of one argument: the request is upgraded to a WebSocket connection
+  and the function is called with the connection map (see connect).
+  Optional opts map may have the following keys:
+  - read-timeout, write-timeout, idle-timeout (int, milliseconds,
+    no timeout if not provided)
+  - cert-file, key-file (string, paths to TLS certificate and key files;
+    if both are provided, the server serves HTTPS; providing only one
+    of them is an error)
+  - middleware (seq of middleware functions to wrap handler with,
+    see wrap-middleware)
+  - on-start (function of one argument, called with the address
+    the server is bound to once it is listening, before it serves
+    requests; useful when addr has port 0, which picks a random port
+    and makes the server available under that address to stop-server).`, "1.0"))
+
+	httpNamespace.InternVar("stop-server", stop_server_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("addr")), NewVectorFrom(MakeSymbol("addr"), MakeSymbol("timeout"))),
+			`Gracefully stops HTTP server running on the TCP network address addr:
+  stops accepting new connections and waits for active requests to complete.
+  Connections that are still active after timeout milliseconds
+  (5000 by default) are closed.`, "1.4"))
+
+	httpNamespace.InternVar("wrap-middleware", wrap_middleware_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("handler"), MakeSymbol("middleware"))),
+			`Returns handler wrapped in middleware. Each middleware is a function
+  that takes a handler and returns a new handler. The first middleware
+  in the seq is the outermost one, i.e. it sees the request first
+  and the response last.`, "1.4"))
 
 }
@@ -0,0 +1,349 @@
+package http
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	. "github.com/candid82/joker/core"
+)
+
+type (
+	HttpClient struct {
+		client *http.Client
+		hash   uint32
+	}
+)
+
+var httpClientType *Type
+
+func MakeHttpClient(client *http.Client) HttpClient {
+	res := HttpClient{client, 0}
+	res.hash = HashPtr(uintptr(unsafe.Pointer(client)))
+	return res
+}
+
+func (c HttpClient) ToString(_escape bool) string {
+	return "#object[HttpClient]"
+}
+
+func (c HttpClient) TypeToString(escape bool) string {
+	return c.GetType().ToString(escape)
+}
+
+func (c HttpClient) Equals(other interface{}) bool {
+	if other, ok := other.(HttpClient); ok {
+		return c.client == other.client
+	}
+	return false
+}
+
+func (c HttpClient) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (c HttpClient) GetType() *Type {
+	return httpClientType
+}
+
+func (c HttpClient) Hash() uint32 {
+	return c.hash
+}
+
+func (c HttpClient) WithInfo(_info *ObjectInfo) Object {
+	return c
+}
+
+func EnsureArgIsHttpClient(args []Object, index int) HttpClient {
+	obj := args[index]
+	if c, yes := obj.(HttpClient); yes {
+		return c
+	}
+	panic(FailArg(obj, "HttpClient", index))
+}
+
+func ExtractHttpClient(args []Object, index int) *http.Client {
+	return EnsureArgIsHttpClient(args, index).client
+}
+
+// Request map keys that configure the transport rather than the request.
+// They are only honored when sending with the default client.
+var transportKeys = []string{"proxy", "insecure?", "ca-cert-file", "client-cert-file", "client-key-file", "cookies?", "max-idle-conns-per-host"}
+
+func optBool(opts Map, key string) bool {
+	if ok, v := opts.Get(MakeKeyword(key)); ok {
+		return ToBool(v)
+	}
+	return false
+}
+
+func optInt(opts Map, key string, def int) int {
+	if ok, v := opts.Get(MakeKeyword(key)); ok {
+		return EnsureObjectIsInt(v, key+": %s").I
+	}
+	return def
+}
+
+func tlsConfig(opts Map) *tls.Config {
+	cfg := &tls.Config{InsecureSkipVerify: optBool(opts, "insecure?")}
+	if caFile := optString(opts, "ca-cert-file"); caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		PanicOnErr(err)
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			panic(RT.NewError("No certificates found in " + caFile))
+		}
+		cfg.RootCAs = pool
+	}
+	certFile, keyFile := optString(opts, "client-cert-file"), optString(opts, "client-key-file")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		PanicOnErr(err)
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg
+}
+
+// redirectPolicy returns CheckRedirect function for http.Client
+// according to :follow-redirects and :max-redirects options,
+// or nil if neither option is provided.
+func redirectPolicy(opts Map) func(*http.Request, []*http.Request) error {
+	okFollow, _ := opts.Get(MakeKeyword("follow-redirects"))
+	okMax, _ := opts.Get(MakeKeyword("max-redirects"))
+	if !okFollow && !okMax {
+		return nil
+	}
+	follow := !okFollow || optBool(opts, "follow-redirects")
+	max := optInt(opts, "max-redirects", 10)
+	return func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		return nil
+	}
+}
+
+func makeClient(opts Map) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig(opts)
+	if proxy := optString(opts, "proxy"); proxy != "" {
+		u, err := url.Parse(proxy)
+		PanicOnErr(err)
+		transport.Proxy = http.ProxyURL(u)
+	}
+	transport.MaxIdleConnsPerHost = optInt(opts, "max-idle-conns-per-host", 0)
+	res := &http.Client{
+		Transport:     transport,
+		Timeout:       optDuration(opts, "timeout"),
+		CheckRedirect: redirectPolicy(opts),
+	}
+	if optBool(opts, "cookies?") {
+		jar, err := cookiejar.New(nil)
+		PanicOnErr(err)
+		res.Jar = jar
+	}
+	return res
+}
+
+// Clients made for requests sent with the default client and
+// transport options, keyed by these options. They are reused, so
+// that their connections (and cookies) are kept between requests.
+var (
+	requestClients      = make(map[string]*http.Client)
+	requestClientsMutex sync.Mutex
+)
+
+// requestClient returns the client for the transport options in
+// request, or nil if there are none.
+func requestClient(request Map) *http.Client {
+	opts := EmptyArrayMap()
+	var key strings.Builder
+	for _, k := range transportKeys {
+		if ok, v := request.Get(MakeKeyword(k)); ok {
+			opts.Add(MakeKeyword(k), v)
+			fmt.Fprintf(&key, "%s %s\n", k, v.ToString(true))
+		}
+	}
+	if opts.Count() == 0 {
+		return nil
+	}
+	requestClientsMutex.Lock()
+	defer requestClientsMutex.Unlock()
+	res, ok := requestClients[key.String()]
+	if !ok {
+		res = makeClient(opts)
+		requestClients[key.String()] = res
+	}
+	return res
+}
+
+// clientForRequest returns client adjusted for per-request options.
+func clientForRequest(client *http.Client, request Map) *http.Client {
+	if client == defaultClient {
+		if c := requestClient(request); c != nil {
+			client = c
+		}
+	}
+	policy := redirectPolicy(request)
+	timeout := optDuration(request, "timeout")
+	if policy == nil && timeout == 0 {
+		return client
+	}
+	c := *client
+	if policy != nil {
+		c.CheckRedirect = policy
+	}
+	if timeout != 0 {
+		c.Timeout = timeout
+	}
+	return &c
+}
+
+func paramName(obj Object) string {
+	switch obj := obj.(type) {
+	case String:
+		return obj.S
+	case Keyword:
+		return obj.ToString(false)[1:]
+	default:
+		return obj.ToString(false)
+	}
+}
+
+func addValues(values url.Values, m Map) {
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		name := paramName(p.Key)
+		switch v := p.Value.(type) {
+		case String:
+			values.Add(name, v.S)
+		case Seqable:
+			for s := v.Seq(); !s.IsEmpty(); s = s.Rest() {
+				values.Add(name, s.First().ToString(false))
+			}
+		default:
+			values.Add(name, v.ToString(false))
+		}
+	}
+}
+
+func queryURL(rawURL string, request Map) string {
+	ok, params := request.Get(MakeKeyword("query-params"))
+	if !ok {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	PanicOnErr(err)
+	q := u.Query()
+	addValues(q, EnsureObjectIsMap(params, "query-params: %s"))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func multipartBody(parts Seqable) (io.Reader, string) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for s := parts.Seq(); !s.IsEmpty(); s = s.Rest() {
+		part := EnsureObjectIsMap(s.First(), "multipart part: %s")
+		name := paramName(getOrPanic(part, MakeKeyword("name"), ":name key must be present in multipart part"))
+		var content []byte
+		filename := optString(part, "filename")
+		if file := optString(part, "file"); file != "" {
+			var err error
+			content, err = ioutil.ReadFile(file)
+			PanicOnErr(err)
+			if filename == "" {
+				filename = filepath.Base(file)
+			}
+		} else {
+			content = []byte(EnsureObjectIsString(getOrPanic(part, MakeKeyword("content"), ":content or :file key must be present in multipart part"), "multipart content: %s").S)
+		}
+		var pw io.Writer
+		var err error
+		if filename != "" || optString(part, "content-type") != "" {
+			h := make(map[string][]string)
+			disposition := fmt.Sprintf("form-data; name=%q", name)
+			if filename != "" {
+				disposition += fmt.Sprintf("; filename=%q", filename)
+			}
+			h["Content-Disposition"] = []string{disposition}
+			contentType := optString(part, "content-type")
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			h["Content-Type"] = []string{contentType}
+			pw, err = w.CreatePart(h)
+		} else {
+			pw, err = w.CreateFormField(name)
+		}
+		PanicOnErr(err)
+		_, err = pw.Write(content)
+		PanicOnErr(err)
+	}
+	PanicOnErr(w.Close())
+	return &b, w.FormDataContentType()
+}
+
+// requestBody returns request body and its content type (if implied by the body kind).
+func requestBody(request Map) (io.Reader, string) {
+	if ok, b := request.Get(MakeKeyword("body")); ok {
+		return bytes.NewReader([]byte(EnsureObjectIsString(b, "body: %s").S)), ""
+	}
+	if ok, params := request.Get(MakeKeyword("form-params")); ok {
+		values := url.Values{}
+		addValues(values, EnsureObjectIsMap(params, "form-params: %s"))
+		return bytes.NewReader([]byte(values.Encode())), "application/x-www-form-urlencoded"
+	}
+	if ok, parts := request.Get(MakeKeyword("multipart")); ok {
+		return multipartBody(EnsureObjectIsSeqable(parts, "multipart: %s"))
+	}
+	return nil, ""
+}
+
+func setBasicAuth(req *http.Request, auth Object) {
+	switch auth := auth.(type) {
+	case Vec:
+		if auth.Count() != 2 {
+			panic(RT.NewError("basic-auth vector must have two elements: user and password"))
+		}
+		req.SetBasicAuth(EnsureObjectIsString(auth.At(0), "basic-auth user: %s").S, EnsureObjectIsString(auth.At(1), "basic-auth password: %s").S)
+	case Map:
+		req.SetBasicAuth(optString(auth, "user"), optString(auth, "password"))
+	default:
+		panic(RT.NewError("basic-auth must be a vector or a map, got " + auth.GetType().ToString(false)))
+	}
+}
+
+func redirectsHistory(resp *http.Response) Object {
+	var urls []string
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		urls = append([]string{r.Response.Request.URL.String()}, urls...)
+	}
+	return MakeStringVector(urls)
+}
+
+func cookiesToMap(cookies []*http.Cookie) Map {
+	res := EmptyArrayMap()
+	for _, c := range cookies {
+		res.Add(MakeString(c.Name), MakeString(c.Value))
+	}
+	return res
+}
+
+func init() {
+	httpClientType = RegType("HttpClient", (*HttpClient)(nil), "Wraps http.Client type")
+}
@@ -1,17 +1,32 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	. "github.com/candid82/joker/core"
 )
 
-var client *http.Client
+type (
+	route struct {
+		method   string
+		segments []string
+		handler  Callable
+	}
+)
+
+var defaultClient *http.Client
+
+// servers maps addresses to running servers so that they can be stopped.
+// Access is guarded by the GIL.
+var servers = map[string]*http.Server{}
 
 func extractMethod(request Map) string {
 	if ok, m := request.Get(MakeKeyword("method")); ok {
@@ -39,12 +54,12 @@ func getOrPanic(m Map, k Object, errMsg string) Object {
 func mapToReq(request Map) *http.Request {
 	method := strings.ToUpper(extractMethod(request))
 	url := EnsureObjectIsString(getOrPanic(request, MakeKeyword("url"), ":url key must be present in request map"), "url: %s").S
-	var reqBody io.Reader
-	if ok, b := request.Get(MakeKeyword("body")); ok {
-		reqBody = strings.NewReader(EnsureObjectIsString(b, "body: %s").S)
-	}
-	req, err := http.NewRequest(method, url, reqBody)
+	reqBody, contentType := requestBody(request)
+	req, err := http.NewRequest(method, queryURL(url, request), reqBody)
 	PanicOnErr(err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	if ok, headers := request.Get(MakeKeyword("headers")); ok {
 		h := EnsureObjectIsMap(headers, "headers: %s")
 		for iter := h.Iter(); iter.HasNext(); {
@@ -55,10 +70,20 @@ func mapToReq(request Map) *http.Request {
 	if ok, host := request.Get(MakeKeyword("host")); ok {
 		req.Host = EnsureObjectIsString(host, "host: %s").S
 	}
+	if ok, auth := request.Get(MakeKeyword("basic-auth")); ok {
+		setBasicAuth(req, auth)
+	}
+	if ok, cookies := request.Get(MakeKeyword("cookies")); ok {
+		c := EnsureObjectIsMap(cookies, "cookies: %s")
+		for iter := c.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			req.AddCookie(&http.Cookie{Name: EnsureObjectIsString(p.Key, "cookie name: %s").S, Value: EnsureObjectIsString(p.Value, "cookie value: %s").S})
+		}
+	}
 	return req
 }
 
-func reqToMap(host String, port String, req *http.Request) Map {
+func reqToMap(host String, port String, scheme Keyword, req *http.Request) Map {
 	defer req.Body.Close()
 	res := EmptyArrayMap()
 	body, err := ioutil.ReadAll(req.Body)
@@ -71,7 +96,7 @@ func reqToMap(host String, port String, req *http.Request) Map {
 	res.Add(MakeKeyword("server-port"), port)
 	res.Add(MakeKeyword("remote-addr"), MakeString(req.RemoteAddr[:strings.LastIndexByte(req.RemoteAddr, byte(':'))]))
 	res.Add(MakeKeyword("protocol"), MakeString(req.Proto))
-	res.Add(MakeKeyword("scheme"), MakeKeyword("http"))
+	res.Add(MakeKeyword("scheme"), scheme)
 	res.Add(MakeKeyword("host"), MakeString(req.Host))
 	headers := EmptyArrayMap()
 	for k, v := range req.Header {
@@ -81,7 +106,7 @@ func reqToMap(host String, port String, req *http.Request) Map {
 	return res
 }
 
-func respToMap(resp *http.Response) Map {
+func respToMap(resp *http.Response) *ArrayMap {
 	defer resp.Body.Close()
 	res := EmptyArrayMap()
 	body, err := ioutil.ReadAll(resp.Body)
@@ -95,6 +120,9 @@ func respToMap(resp *http.Response) Map {
 	res.Add(MakeKeyword("headers"), respHeaders)
 	// TODO: 32-bit issue
 	res.Add(MakeKeyword("content-length"), MakeInt(int(resp.ContentLength)))
+	res.Add(MakeKeyword("cookies"), cookiesToMap(resp.Cookies()))
+	res.Add(MakeKeyword("url"), MakeString(resp.Request.URL.String()))
+	res.Add(MakeKeyword("redirects"), redirectsHistory(resp))
 	return res
 }
 
@@ -133,47 +161,280 @@ func mapToResp(response Map, w http.ResponseWriter) {
 	io.WriteString(w, body)
 }
 
-func sendRequest(request Map) Map {
+func sendRequest(client *http.Client, request Map) Map {
 	req := mapToReq(request)
-	RT.GIL.Unlock()
+	client = clientForRequest(client, request)
+	start := time.Now()
+	in := ReleaseGIL()
 	resp, err := client.Do(req)
-	RT.GIL.Lock()
+	in.Enter()
 	PanicOnErr(err)
-	return respToMap(resp)
+	res := respToMap(resp)
+	res.Add(MakeKeyword("request-time"), MakeInt(int(time.Since(start)/time.Millisecond)))
+	return res
 }
 
-func startServer(addr string, handler Callable) Object {
+func optDuration(opts Map, key string) time.Duration {
+	if ok, v := opts.Get(MakeKeyword(key)); ok {
+		return time.Duration(EnsureObjectIsInt(v, key+": %s").I) * time.Millisecond
+	}
+	return 0
+}
+
+func optString(opts Map, key string) string {
+	if ok, v := opts.Get(MakeKeyword(key)); ok {
+		return EnsureObjectIsString(v, key+": %s").S
+	}
+	return ""
+}
+
+// serve listens on addr and serves requests with the handler made by
+// makeHandler for the address the server is bound to (which differs
+// from addr if its port is 0) and the scheme.
+func serve(addr string, makeHandler func(addr string, scheme Keyword) http.Handler, opts Map) Object {
+	if _, ok := servers[addr]; ok {
+		panic(RT.NewError("HTTP server is already running on " + addr))
+	}
+	certFile := optString(opts, "cert-file")
+	keyFile := optString(opts, "key-file")
+	if (certFile == "") != (keyFile == "") {
+		panic(RT.NewError("HTTP server requires both cert-file and key-file to serve HTTPS"))
+	}
+	scheme := MakeKeyword("http")
+	if certFile != "" {
+		scheme = MakeKeyword("https")
+	}
+	if addr == "" {
+		addr = ":" + scheme.ToString(false)[1:]
+	}
+	l, err := net.Listen("tcp", addr)
+	PanicOnErr(err)
+	if _, port, _ := net.SplitHostPort(addr); port == "0" {
+		addr = l.Addr().String()
+	}
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      makeHandler(addr, scheme),
+		ReadTimeout:  optDuration(opts, "read-timeout"),
+		WriteTimeout: optDuration(opts, "write-timeout"),
+		IdleTimeout:  optDuration(opts, "idle-timeout"),
+	}
+	servers[addr] = server
+	if ok, onStart := opts.Get(MakeKeyword("on-start")); ok {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					delete(servers, addr)
+					l.Close()
+					panic(r)
+				}
+			}()
+			EnsureObjectIsCallable(onStart, "on-start: %s").Call([]Object{MakeString(addr)})
+		}()
+	}
+	in := ReleaseGIL()
+	if certFile != "" {
+		err = server.ServeTLS(l, certFile, keyFile)
+	} else {
+		err = server.Serve(l)
+	}
+	in.Enter()
+	if servers[addr] == server {
+		delete(servers, addr)
+	}
+	if err != http.ErrServerClosed {
+		PanicOnErr(err)
+	}
+	return NIL
+}
+
+func splitAddr(addr string) (String, String) {
 	i := strings.LastIndexByte(addr, byte(':'))
-	host, port := MakeString(addr), MakeString("")
-	if i != -1 {
-		host = MakeString(addr[:i])
-		port = MakeString(addr[i+1:])
-	}
-	RT.GIL.Unlock()
-	defer RT.GIL.Lock()
-	err := http.ListenAndServe(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		RT.GIL.Lock()
+	if i == -1 {
+		return MakeString(addr), MakeString("")
+	}
+	return MakeString(addr[:i]), MakeString(addr[i+1:])
+}
+
+// makeHandler returns http.Handler that calls Joker handler.
+// If record is not nil, it is called with each request map.
+func makeHandler(addr string, scheme Keyword, handler Callable, record func(Map)) http.Handler {
+	host, port := splitAddr(addr)
+	in := ActiveInterpreter()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		defer func() {
-			RT.GIL.Unlock()
 			if r := recover(); r != nil {
 				w.WriteHeader(500)
 				io.WriteString(w, "Internal server error")
 				fmt.Fprintln(os.Stderr, r)
 			}
 		}()
-		response := handler.Call([]Object{reqToMap(host, port, req)})
-		mapToResp(EnsureObjectIsMap(response, "HTTP response: %s"), w)
-	}))
-	PanicOnErr(err)
-	return NIL
+		// Returns the websocket handler, if the response is a websocket.
+		onConnect := func() Callable {
+			in.Enter()
+			defer in.Leave()
+			request := reqToMap(host, port, scheme, req)
+			if record != nil {
+				record(request)
+			}
+			response := EnsureObjectIsMap(handler.Call([]Object{request}), "HTTP response: %s")
+			if ok, onConnect := response.Get(MakeKeyword("websocket")); ok {
+				return EnsureObjectIsCallable(onConnect, "websocket: %s")
+			}
+			mapToResp(response, w)
+			return nil
+		}()
+		if onConnect != nil {
+			serveWebSocket(w, req, onConnect, in)
+		}
+	})
+}
+
+func startServer(addr string, handler Callable, opts Map) Object {
+	if ok, middleware := opts.Get(MakeKeyword("middleware")); ok {
+		handler = EnsureObjectIsCallable(wrapMiddleware(handler, EnsureObjectIsSeqable(middleware, "middleware: %s")), "middleware result: %s")
+	}
+	return serve(addr, func(addr string, scheme Keyword) http.Handler {
+		return makeHandler(addr, scheme, handler, nil)
+	}, opts)
 }
 
 func startFileServer(addr string, root string) Object {
-	err := http.ListenAndServe(addr, http.FileServer(http.Dir(root)))
+	return serve(addr, func(string, Keyword) http.Handler {
+		return http.FileServer(http.Dir(root))
+	}, EmptyArrayMap())
+}
+
+func stopServer(addr string, timeout int) Object {
+	server, ok := servers[addr]
+	if !ok {
+		panic(RT.NewError("No HTTP server is running on " + addr))
+	}
+	delete(servers, addr)
+	delete(mockServers, addr)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+	in := ReleaseGIL()
+	err := server.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		err = server.Close()
+	}
+	in.Enter()
 	PanicOnErr(err)
 	return NIL
 }
 
+func wrapMiddleware(handler Callable, middleware Seqable) Object {
+	mws := ToSlice(middleware.Seq())
+	res := handler.(Object)
+	for i := len(mws) - 1; i >= 0; i-- {
+		mw := EnsureObjectIsCallable(mws[i], "middleware: %s")
+		res = mw.Call([]Object{res})
+	}
+	return res
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func routeMethod(m Object) string {
+	switch m := m.(type) {
+	case String:
+		return strings.ToLower(m.S)
+	case Keyword:
+		return strings.ToLower(m.ToString(false)[1:])
+	case Symbol:
+		return strings.ToLower(m.ToString(false))
+	default:
+		panic(RT.NewError("Route method must be a string, keyword or symbol, got " + m.GetType().ToString(false)))
+	}
+}
+
+func parseRoutes(routes Seqable) []route {
+	var res []route
+	for s := routes.Seq(); !s.IsEmpty(); s = s.Rest() {
+		r := EnsureObjectIsVec(s.First(), "route: %s")
+		if r.Count() != 3 {
+			panic(RT.NewError("Route must be a vector of method, path pattern and handler, got " + r.ToString(true)))
+		}
+		res = append(res, route{
+			method:   routeMethod(r.At(0)),
+			segments: splitPath(EnsureObjectIsString(r.At(1), "route path: %s").S),
+			handler:  EnsureObjectIsCallable(r.At(2), "route handler: %s"),
+		})
+	}
+	return res
+}
+
+// matchRoute matches path segments against route's pattern segments,
+// returning path params extracted from :param and *param segments.
+func matchRoute(pattern []string, path []string) (Map, bool) {
+	params := EmptyArrayMap()
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "*") {
+			params.Add(MakeKeyword(seg[1:]), MakeString(strings.Join(path[i:], "/")))
+			return params, true
+		}
+		if i >= len(path) {
+			return nil, false
+		}
+		if strings.HasPrefix(seg, ":") {
+			if path[i] == "" {
+				return nil, false
+			}
+			params.Add(MakeKeyword(seg[1:]), MakeString(path[i]))
+		} else if seg != path[i] {
+			return nil, false
+		}
+	}
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+	return params, true
+}
+
+func textResponse(status int, body string) *ArrayMap {
+	res := EmptyArrayMap()
+	res.Add(MakeKeyword("status"), MakeInt(status))
+	res.Add(MakeKeyword("body"), MakeString(body))
+	return res
+}
+
+func router(routes Seqable) Object {
+	rs := parseRoutes(routes)
+	return Proc{
+		Fn: func(args []Object) Object {
+			CheckArity(args, 1, 1)
+			request := EnsureObjectIsMap(args[0], "HTTP request: %s")
+			method := routeMethod(getOrPanic(request, MakeKeyword("request-method"), ":request-method key must be present in request map"))
+			path := splitPath(EnsureObjectIsString(getOrPanic(request, MakeKeyword("uri"), ":uri key must be present in request map"), "uri: %s").S)
+			var allowed []string
+			for _, r := range rs {
+				params, ok := matchRoute(r.segments, path)
+				if !ok {
+					continue
+				}
+				if r.method != "any" && r.method != method {
+					allowed = append(allowed, strings.ToUpper(r.method))
+					continue
+				}
+				return r.handler.Call([]Object{request.Assoc(MakeKeyword("path-params"), params)})
+			}
+			if len(allowed) > 0 {
+				res := textResponse(405, "Method Not Allowed")
+				headers := EmptyArrayMap()
+				headers.Add(MakeString("Allow"), MakeString(strings.Join(allowed, ", ")))
+				res.Add(MakeKeyword("headers"), headers)
+				return res
+			}
+			return textResponse(404, "Not Found")
+		},
+		Name:    "router",
+		Package: "std/http",
+	}
+}
+
 func initNative() {
-	client = &http.Client{}
+	defaultClient = &http.Client{}
 }
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/candid82/joker/core"
+)
+
+type (
+	mockServer struct {
+		requests []Object
+	}
+)
+
+// mockServers maps addresses of running mock servers to their recorded
+// requests. Access is guarded by the GIL.
+var mockServers = map[string]*mockServer{}
+
+func mockReq(request Map) *http.Request {
+	if ok, url := request.Get(MakeKeyword("url")); ok {
+		if s, ok := url.(String); ok && strings.HasPrefix(s.S, "/") {
+			request = request.Assoc(MakeKeyword("url"), MakeString("http://localhost"+s.S)).(Map)
+		}
+	}
+	req := mapToReq(request)
+	req.RemoteAddr = "127.0.0.1:0"
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	return req
+}
+
+func reqToMockMap(req *http.Request) Map {
+	scheme := req.URL.Scheme
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+	return reqToMap(MakeString(req.URL.Hostname()), MakeString(port), MakeKeyword(scheme), req)
+}
+
+func mockRequest(request Map) Map {
+	return reqToMockMap(mockReq(request))
+}
+
+func invoke(handler Callable, request Map) Map {
+	req := mockReq(request)
+	response := EnsureObjectIsMap(handler.Call([]Object{reqToMockMap(req)}), "HTTP response: %s")
+	rec := httptest.NewRecorder()
+	mapToResp(response, rec)
+	resp := rec.Result()
+	if resp.ContentLength == -1 && resp.Header.Get("Content-Length") == "" {
+		resp.ContentLength = int64(rec.Body.Len())
+	}
+	resp.Request = req
+	return respToMap(resp)
+}
+
+func mockHandler(responses Object) Callable {
+	switch r := responses.(type) {
+	case Map:
+		return Proc{
+			Fn: func(args []Object) Object {
+				return r
+			},
+			Name:    "mock-handler",
+			Package: "std/http",
+		}
+	case Vec:
+		if r.Count() == 0 {
+			panic(RT.NewError("Mock server responses must not be empty"))
+		}
+		i := 0
+		return Proc{
+			Fn: func(args []Object) Object {
+				res := r.At(i)
+				if i < r.Count()-1 {
+					i++
+				}
+				return res
+			},
+			Name:    "mock-handler",
+			Package: "std/http",
+		}
+	case Callable:
+		return r
+	}
+	panic(RT.NewError("Mock server responses must be a map, a vector or a function, got " + responses.GetType().ToString(false)))
+}
+
+func startMockServer(responses Object) Map {
+	handler := mockHandler(responses)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	PanicOnErr(err)
+	addr := l.Addr().String()
+	mock := &mockServer{}
+	server := &http.Server{
+		Handler: makeHandler(addr, MakeKeyword("http"), handler, func(request Map) {
+			mock.requests = append(mock.requests, request)
+		}),
+	}
+	servers[addr] = server
+	mockServers[addr] = mock
+	go func() {
+		server.Serve(l)
+		RT.GIL.Lock()
+		if servers[addr] == server {
+			delete(servers, addr)
+		}
+		if mockServers[addr] == mock {
+			delete(mockServers, addr)
+		}
+		RT.GIL.Unlock()
+	}()
+	res := EmptyArrayMap()
+	res.Add(MakeKeyword("addr"), MakeString(addr))
+	res.Add(MakeKeyword("url"), MakeString("http://"+addr))
+	return res
+}
+
+func mockRequests(addr string) Object {
+	mock, ok := mockServers[addr]
+	if !ok {
+		panic(RT.NewError("No mock server was started on " + addr))
+	}
+	return NewVectorFrom(mock.requests...)
+}
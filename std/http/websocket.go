@@ -0,0 +1,93 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	. "github.com/candid82/joker/core"
+	"golang.org/x/net/websocket"
+)
+
+// bridgeWebSocket starts goroutines that pass messages between ws
+// and a pair of Joker channels of interpreter interp, and returns
+// connection map with these channels. The returned Go channel
+// is closed when the connection is closed.
+func bridgeWebSocket(ws *websocket.Conn, interp *Interpreter) (Map, chan struct{}) {
+	in := make(chan FutureResult)
+	out := make(chan FutureResult)
+	inCh, outCh := MakeChannel(in), MakeChannel(out)
+	done := make(chan struct{})
+	go func() {
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				break
+			}
+			if !inCh.Send(MakeString(msg)) {
+				// The in channel has been closed.
+				break
+			}
+		}
+		interp.Enter()
+		inCh.Close()
+		outCh.Close()
+		interp.Leave()
+		close(done)
+	}()
+	go func() {
+		for r := range out {
+			if err := websocket.Message.Send(ws, r.Value().ToString(false)); err != nil {
+				break
+			}
+		}
+		ws.Close()
+	}()
+	res := EmptyArrayMap()
+	res.Add(MakeKeyword("in"), inCh)
+	res.Add(MakeKeyword("out"), outCh)
+	return res, done
+}
+
+// serveWebSocket upgrades req to WebSocket connection and calls
+// onConnect with the connection map in interpreter in.
+// Must be called without holding the GIL.
+func serveWebSocket(w http.ResponseWriter, req *http.Request, onConnect Callable, in *Interpreter) {
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		conn, done := bridgeWebSocket(ws, in)
+		func() {
+			in.Enter()
+			defer func() {
+				in.Leave()
+				if r := recover(); r != nil {
+					ws.Close()
+					fmt.Fprintln(os.Stderr, r)
+				}
+			}()
+			onConnect.Call([]Object{conn})
+		}()
+		<-done
+	}}.ServeHTTP(w, req)
+}
+
+func connect(url string, opts Map) Map {
+	origin := optString(opts, "origin")
+	if origin == "" {
+		origin = "http://localhost/"
+	}
+	config, err := websocket.NewConfig(url, origin)
+	PanicOnErr(err)
+	if ok, headers := opts.Get(MakeKeyword("headers")); ok {
+		h := EnsureObjectIsMap(headers, "headers: %s")
+		for iter := h.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			config.Header.Add(EnsureObjectIsString(p.Key, "header name: %s").S, EnsureObjectIsString(p.Value, "header value: %s").S)
+		}
+	}
+	in := ReleaseGIL()
+	ws, err := websocket.DialConfig(config)
+	in.Enter()
+	PanicOnErr(err)
+	conn, _ := bridgeWebSocket(ws, in)
+	return conn
+}
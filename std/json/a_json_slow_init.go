@@ -31,7 +31,10 @@ func InternsOrThunks() {
 
 	jsonNamespace.InternVar("write-string", write_string_,
 		MakeMeta(
-			NewListFrom(NewVectorFrom(MakeSymbol("v"))),
-			`Returns the JSON encoding of v.`, "1.0"))
+			NewListFrom(NewVectorFrom(MakeSymbol("v")), NewVectorFrom(MakeSymbol("v"), MakeSymbol("opts"))),
+			`Returns the JSON encoding of v.
+  Optional opts map may have the following keys:
+  :escape-html? - if false, <, > and & in strings are not escaped
+  as \u003c, \u003e and \u0026 (true by default).`, "1.0"))
 
 }
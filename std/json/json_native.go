@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	. "github.com/candid82/joker/core"
@@ -130,12 +131,16 @@ func jsonSeqOpts(src Object, opts Map) Object {
 	return jsonLazySeq()
 }
 
-func writeString(obj Object) String {
-	res, err := json.Marshal(fromObject(obj))
-	if err != nil {
+func writeString(obj Object, opts Map) String {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if ok, v := opts.Get(MakeKeyword("escape-html?")); ok {
+		enc.SetEscapeHTML(ToBool(v))
+	}
+	if err := enc.Encode(fromObject(obj)); err != nil {
 		panic(RT.NewError("Cannot encode value to json: " + err.Error()))
 	}
-	return String{S: string(res)}
+	return String{S: strings.TrimSuffix(b.String(), "\n")}
 }
 
 func initNative() {
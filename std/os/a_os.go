@@ -650,6 +650,149 @@ func __ppid_(_args []Object) Object {
 	return NIL
 }
 
+var __process__P ProcFn = __process_
+var process_ Proc = Proc{Fn: __process__P, Name: "process_", Package: "std/os"}
+
+func __process_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		command := ExtractObject(_args, 0)
+		_res := startPipeline(command, EmptyArrayMap())
+		return _res
+
+	case _c == 2:
+		command := ExtractObject(_args, 0)
+		opts := ExtractMap(_args, 1)
+		_res := startPipeline(command, opts)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_kill__P ProcFn = __process_kill_
+var process_kill_ Proc = Proc{Fn: __process_kill__P, Name: "process_kill_", Package: "std/os"}
+
+func __process_kill_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := killPipeline(p, 0x9)
+		return _res
+
+	case _c == 2:
+		p := ExtractProcess(_args, 0)
+		signal := ExtractInt(_args, 1)
+		_res := killPipeline(p, signal)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_lines__P ProcFn = __process_lines_
+var process_lines_ Proc = Proc{Fn: __process_lines__P, Name: "process_lines_", Package: "std/os"}
+
+func __process_lines_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := processLines(p, ":stdout")
+		return _res
+
+	case _c == 2:
+		p := ExtractProcess(_args, 0)
+		stream := ExtractKeyword(_args, 1)
+		_res := processLines(p, stream)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_stderr__P ProcFn = __process_stderr_
+var process_stderr_ Proc = Proc{Fn: __process_stderr__P, Name: "process_stderr_", Package: "std/os"}
+
+func __process_stderr_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := processStream(p, ":stderr")
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_stdin__P ProcFn = __process_stdin_
+var process_stdin_ Proc = Proc{Fn: __process_stdin__P, Name: "process_stdin_", Package: "std/os"}
+
+func __process_stdin_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := p.stdin
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_stdout__P ProcFn = __process_stdout_
+var process_stdout_ Proc = Proc{Fn: __process_stdout__P, Name: "process_stdout_", Package: "std/os"}
+
+func __process_stdout_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := processStream(p, ":stdout")
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __process_wait__P ProcFn = __process_wait_
+var process_wait_ Proc = Proc{Fn: __process_wait__P, Name: "process_wait_", Package: "std/os"}
+
+func __process_wait_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		p := ExtractProcess(_args, 0)
+		_res := waitProcess(p, -1)
+		return _res
+
+	case _c == 2:
+		p := ExtractProcess(_args, 0)
+		timeout := ExtractInt(_args, 1)
+		_res := waitProcess(p, timeout)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
 var __read_link__P ProcFn = __read_link_
 var read_link_ Proc = Proc{Fn: __read_link__P, Name: "read_link_", Package: "std/os"}
 
@@ -289,6 +289,67 @@ func InternsOrThunks() {
 			NewListFrom(NewVectorFrom()),
 			`Returns the process id of the caller's parent.`, "1.0").Plus(MakeKeyword("tag"), String{S: "Int"}))
 
+	osNamespace.InternVar("process", process_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("command")), NewVectorFrom(MakeSymbol("command"), MakeSymbol("opts"))),
+			`Starts command without waiting for it to finish and returns a Process object.
+  command is either a program name or a pipeline: a seq of commands,
+  each being a vector of a program name followed by its arguments,
+  e.g. [["ls" "-l"] ["grep" "joke"]]. Stdout of each command
+  of the pipeline is connected to stdin of the next one (no shell is involved).
+  opts is a map with the same keys as in exec (:args only applies when command
+  is a program name) and the following additional key:
+  :env - map of environment variables overriding those of the current process
+  (nil value unsets the variable).
+  Unless redirected with :stdin, :stdout or :stderr options,
+  the process's standard streams are available via process-stdin,
+  process-stdout and process-stderr. The commands are put into a new
+  process group (where supported), which process-kill signals as a whole.`, "1.4"))
+
+	osNamespace.InternVar("process-kill", process_kill_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p")), NewVectorFrom(MakeSymbol("p"), MakeSymbol("signal"))),
+			`Sends signal (SIGKILL by default) to process p. If p was started in its own
+  process group, the whole group (including all commands of the pipeline and
+  their children) is signaled. Does nothing if p has already exited.`, "1.4"))
+
+	osNamespace.InternVar("process-lines", process_lines_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p")), NewVectorFrom(MakeSymbol("p"), MakeSymbol("stream"))),
+			`Returns a channel that receives lines read from stdout (or stream,
+  which must be either :stdout or :stderr) of process p.
+  The channel is closed when the stream reaches EOF.`, "1.4"))
+
+	osNamespace.InternVar("process-stderr", process_stderr_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p"))),
+			`Returns IOReader connected to stderr of process p (shared by all commands of the pipeline).
+  Throws an error if stderr was redirected.`, "1.4"))
+
+	osNamespace.InternVar("process-stdin", process_stdin_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p"))),
+			`Returns IOWriter connected to stdin of process p (the first command of the pipeline),
+  or nil if stdin was redirected. Close it with joker.io/close to signal end of input.`, "1.4"))
+
+	osNamespace.InternVar("process-stdout", process_stdout_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p"))),
+			`Returns IOReader connected to stdout of process p (the last command of the pipeline).
+  Throws an error if stdout was redirected.`, "1.4"))
+
+	osNamespace.InternVar("process-wait", process_wait_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("p")), NewVectorFrom(MakeSymbol("p"), MakeSymbol("timeout"))),
+			`Waits for process p to exit and returns a map with the following keys:
+  :success - whether or not the execution was successful,
+  :err-msg (present iff :success if false) - string capturing error object returned by Go runtime,
+  :exit - exit code of the program (the last command of the pipeline).
+  If timeout (in milliseconds) is provided and the process is still running
+  after it elapses, returns nil. Timeout of 0 checks the status without waiting.
+  Once the process has exited, closes its stdin, stdout and stderr pipes
+  (except the ones read by process-lines), so read them before waiting.`, "1.4"))
+
 	osNamespace.InternVar("read-link", read_link_,
 		MakeMeta(
 			NewListFrom(NewVectorFrom(MakeSymbol("name"))),
@@ -103,7 +103,8 @@ func parseExecOpts(opts Map) (dir string, args []string, stdin io.Reader, stdout
 			switch s := stdinObj.(type) {
 			case Nil:
 			case *IOReader:
-				stdin = s.Reader
+				// Commands read stdin without the GIL.
+				stdin = rawReader(s.Reader)
 			case io.Reader:
 				stdin = s
 			case String:
@@ -0,0 +1,362 @@
+package os
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	. "github.com/candid82/joker/core"
+)
+
+type (
+	// Process is a running command or a pipeline of commands
+	// started by joker.os/process.
+	Process struct {
+		cmds   []*exec.Cmd
+		stdin  Object
+		stdout Object
+		stderr Object
+		done   chan struct{}
+		err    error // error returned by Wait of the last command
+		hash   uint32
+		// Parent ends of the pipes, closed by process-wait once
+		// the process has exited.
+		files []*os.File
+	}
+
+	// gilReleasingReader reads from the parent end of a pipe with the
+	// GIL released, so that a slow child doesn't stall other goroutines.
+	// It must only be read by code holding the GIL; code reading without
+	// it (such as the goroutines started by exec) uses the file directly.
+	gilReleasingReader struct {
+		f *os.File
+	}
+)
+
+var processType *Type
+
+func (p *Process) ToString(escape bool) string {
+	return "#object[Process]"
+}
+
+func (p *Process) TypeToString(escape bool) string {
+	return p.GetType().ToString(escape)
+}
+
+func (p *Process) Equals(other interface{}) bool {
+	return p == other
+}
+
+func (p *Process) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (p *Process) GetType() *Type {
+	return processType
+}
+
+func (p *Process) Hash() uint32 {
+	return p.hash
+}
+
+func (p *Process) WithInfo(info *ObjectInfo) Object {
+	return p
+}
+
+func EnsureArgIsProcess(args []Object, index int) *Process {
+	obj := args[index]
+	if p, yes := obj.(*Process); yes {
+		return p
+	}
+	panic(FailArg(obj, "Process", index))
+}
+
+func ExtractProcess(args []Object, index int) *Process {
+	return EnsureArgIsProcess(args, index)
+}
+
+// commandSpecs returns program names with their arguments for each
+// command of the pipeline. command is either a program name
+// (with arguments taken from :args option) or a seq of commands,
+// each being a seq of a program name followed by its arguments.
+func commandSpecs(command Object, args []string) [][]string {
+	if name, ok := command.(String); ok {
+		return [][]string{append([]string{name.S}, args...)}
+	}
+	var res [][]string
+	for s := EnsureObjectIsSeqable(command, "command: %s").Seq(); !s.IsEmpty(); s = s.Rest() {
+		var spec []string
+		for c := EnsureObjectIsSeqable(s.First(), "command: %s").Seq(); !c.IsEmpty(); c = c.Rest() {
+			spec = append(spec, EnsureObjectIsString(c.First(), "command: %s").S)
+		}
+		if len(spec) == 0 {
+			panic(RT.NewError("Pipeline command must not be empty"))
+		}
+		res = append(res, spec)
+	}
+	if len(res) == 0 {
+		panic(RT.NewError("Pipeline must have at least one command"))
+	}
+	return res
+}
+
+// processEnv returns the environment of the current process
+// with overrides from :env option applied (nil values unset variables),
+// or nil (meaning inherit the environment) if there is no such option.
+func processEnv(opts Map) []string {
+	ok, envObj := opts.Get(MakeKeyword("env"))
+	if !ok {
+		return nil
+	}
+	overrides := EnsureObjectIsMap(envObj, "env: %s")
+	var res []string
+	for _, kv := range os.Environ() {
+		if ok, _ := overrides.Get(MakeString(kv[:strings.IndexByte(kv, '=')])); !ok {
+			res = append(res, kv)
+		}
+	}
+	for iter := overrides.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		if !p.Value.Equals(NIL) {
+			res = append(res, EnsureObjectIsString(p.Key, "env: %s").S+"="+EnsureObjectIsString(p.Value, "env: %s").S)
+		}
+	}
+	return res
+}
+
+func startPipeline(command Object, opts Map) Object {
+	dir, args, stdin, stdout, stderr := parseExecOpts(opts)
+	specs := commandSpecs(command, args)
+	env := processEnv(opts)
+	p := &Process{stdin: NIL, stdout: NIL, stderr: NIL, done: make(chan struct{})}
+	p.hash = HashPtr(uintptr(unsafe.Pointer(p)))
+
+	// Child ends of the pipes, closed in this process once the commands are started.
+	var childFiles, parentFiles []*os.File
+	pipe := func() (*os.File, *os.File) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeFiles(childFiles)
+			closeFiles(parentFiles)
+			panic(RT.NewError(err.Error()))
+		}
+		return r, w
+	}
+	if stdin == nil {
+		r, w := pipe()
+		childFiles, parentFiles = append(childFiles, r), append(parentFiles, w)
+		stdin = r
+		p.stdin = MakeIOWriter(w)
+	}
+	if stdout == nil {
+		r, w := pipe()
+		childFiles, parentFiles = append(childFiles, w), append(parentFiles, r)
+		stdout = w
+		p.stdout = MakeIOReader(gilReleasingReader{r})
+	}
+	if stderr == nil {
+		r, w := pipe()
+		childFiles, parentFiles = append(childFiles, w), append(parentFiles, r)
+		stderr = w
+		p.stderr = MakeIOReader(gilReleasingReader{r})
+	}
+
+	in := stdin
+	for i, spec := range specs {
+		cmd := exec.Command(spec[0], spec[1:]...)
+		cmd.Dir = dir
+		cmd.Env = env
+		cmd.Stdin = in
+		cmd.Stderr = stderr
+		if i == len(specs)-1 {
+			cmd.Stdout = stdout
+		} else {
+			r, w := pipe()
+			childFiles = append(childFiles, r, w)
+			cmd.Stdout = w
+			in = r
+		}
+		pgid := 0
+		if i > 0 {
+			pgid = p.cmds[0].Process.Pid
+		}
+		setProcessGroup(cmd, pgid)
+		if err := cmd.Start(); err != nil {
+			closeFiles(childFiles)
+			closeFiles(parentFiles)
+			if i > 0 {
+				signalProcesses(p.cmds, syscall.SIGKILL)
+				for _, c := range p.cmds {
+					c.Wait()
+				}
+			}
+			panic(RT.NewError(err.Error()))
+		}
+		p.cmds = append(p.cmds, cmd)
+	}
+	closeFiles(childFiles)
+	p.files = parentFiles
+
+	go func() {
+		for _, cmd := range p.cmds {
+			p.err = cmd.Wait()
+		}
+		close(p.done)
+	}()
+	return p
+}
+
+func (r gilReleasingReader) Read(b []byte) (int, error) {
+	in := ReleaseGIL()
+	n, err := r.f.Read(b)
+	in.Enter()
+	return n, err
+}
+
+func (r gilReleasingReader) Close() error {
+	return r.f.Close()
+}
+
+// rawReader returns the reader to use when reading without the GIL.
+func rawReader(r io.Reader) io.Reader {
+	if g, ok := r.(gilReleasingReader); ok {
+		return g.f
+	}
+	return r
+}
+
+// releaseFile removes f from the files closed by process-wait.
+func (p *Process) releaseFile(f *os.File) {
+	for i, pf := range p.files {
+		if pf == f {
+			p.files = append(p.files[:i:i], p.files[i+1:]...)
+			return
+		}
+	}
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+func (p *Process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Process) result() Map {
+	res := EmptyArrayMap()
+	res.Add(MakeKeyword("success"), Boolean{B: p.err == nil})
+	if p.err != nil {
+		res.Add(MakeKeyword("err-msg"), String{S: p.err.Error()})
+	}
+	exitCode := defaultFailedCode
+	if state := p.cmds[len(p.cmds)-1].ProcessState; state != nil {
+		exitCode = state.ExitCode()
+	}
+	res.Add(MakeKeyword("exit"), Int{I: exitCode})
+	return res
+}
+
+// reap closes the parent ends of the pipes of the exited process.
+// Unread output is discarded, as with exec.Cmd's pipes.
+func (p *Process) reap() Map {
+	closeFiles(p.files)
+	p.files = nil
+	return p.result()
+}
+
+// waitProcess waits for the process to exit and returns its exit status,
+// or nil if it's still running after timeout milliseconds.
+// Negative timeout means wait indefinitely.
+func waitProcess(p *Process, timeout int) Object {
+	if timeout < 0 {
+		in := ReleaseGIL()
+		<-p.done
+		in.Enter()
+		return p.reap()
+	}
+	timer := time.NewTimer(time.Duration(timeout) * time.Millisecond)
+	defer timer.Stop()
+	in := ReleaseGIL()
+	select {
+	case <-p.done:
+	case <-timer.C:
+	}
+	in.Enter()
+	if p.exited() {
+		return p.reap()
+	}
+	return NIL
+}
+
+func killPipeline(p *Process, signal int) Object {
+	if p.exited() {
+		return NIL
+	}
+	PanicOnErr(signalProcesses(p.cmds, syscall.Signal(signal)))
+	return NIL
+}
+
+func processStream(p *Process, stream string) Object {
+	var res Object
+	switch stream {
+	case ":stdout":
+		res = p.stdout
+	case ":stderr":
+		res = p.stderr
+	default:
+		panic(RT.NewError("stream must be either :stdout or :stderr, got " + stream))
+	}
+	if res.Equals(NIL) {
+		panic(RT.NewError("Process " + stream + " was redirected"))
+	}
+	return res
+}
+
+// processLines returns a channel that receives lines (without line terminators)
+// read from the process's stdout or stderr, and is closed at EOF.
+// The stream is read (and closed at EOF) off the GIL, so process-wait
+// leaves it open.
+func processLines(p *Process, stream string) Object {
+	r := rawReader(processStream(p, stream).(*IOReader).Reader)
+	if f, ok := r.(*os.File); ok {
+		p.releaseFile(f)
+	}
+	lines := make(chan FutureResult)
+	ch := MakeChannel(lines)
+	in := ActiveInterpreter()
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if !ch.Send(MakeString(scanner.Text())) {
+				// The channel has been closed: stop scanning, but keep
+				// reading so that the process doesn't block on a full pipe.
+				io.Copy(io.Discard, r)
+				break
+			}
+		}
+		if c, ok := r.(io.Closer); ok {
+			c.Close()
+		}
+		in.Enter()
+		ch.Close()
+		in.Leave()
+	}()
+	return ch
+}
+
+func init() {
+	processType = RegType("Process", (*Process)(nil), "Process started by joker.os/process")
+}
@@ -0,0 +1,31 @@
+//go:build plan9 || windows
+// +build plan9 windows
+
+package os
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op: process groups are not supported on this platform.
+func setProcessGroup(cmd *exec.Cmd, pgid int) {
+}
+
+// signalProcesses sends signal to each of cmds.
+func signalProcesses(cmds []*exec.Cmd, signal syscall.Signal) error {
+	var res error
+	for _, cmd := range cmds {
+		var err error
+		if signal == syscall.SIGKILL {
+			err = cmd.Process.Kill()
+		} else {
+			err = cmd.Process.Signal(signal)
+		}
+		if err != nil && err != os.ErrProcessDone {
+			res = err
+		}
+	}
+	return res
+}
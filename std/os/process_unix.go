@@ -0,0 +1,24 @@
+//go:build !plan9 && !windows
+// +build !plan9,!windows
+
+package os
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd a member of the process group pgid,
+// or the leader of a new process group if pgid is 0.
+func setProcessGroup(cmd *exec.Cmd, pgid int) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: pgid}
+}
+
+// signalProcesses sends signal to the process group of cmds.
+func signalProcesses(cmds []*exec.Cmd, signal syscall.Signal) error {
+	err := syscall.Kill(-cmds[0].Process.Pid, signal)
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
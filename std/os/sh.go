@@ -32,9 +32,9 @@ func sh(dir string, stdin io.Reader, stdout io.Writer, stderr io.Writer, name st
 	err := cmd.Start()
 	PanicOnErr(err)
 
-	RT.GIL.Unlock()
+	in := ReleaseGIL()
 	err = cmd.Wait()
-	RT.GIL.Lock()
+	in.Enter()
 
 	res := EmptyArrayMap()
 	res.Add(MakeKeyword("success"), Boolean{B: err == nil})
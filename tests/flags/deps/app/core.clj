@@ -0,0 +1,6 @@
+(ns app.core
+  (:require [app.db :as db]
+            [app.http :as http]))
+
+(defn -main []
+  (http/serve (db/connect)))
@@ -0,0 +1,6 @@
+(ns app.db
+  (:require [clojure.string :as str]
+            [app.http.client :as client]))
+
+(defn connect []
+  (client/fetch (str/trim " db ")))
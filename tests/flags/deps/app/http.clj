@@ -0,0 +1,5 @@
+(ns app.http
+  (:require [app.core]))
+
+(defn serve [conn]
+  conn)
@@ -0,0 +1,4 @@
+(ns app.http.client)
+
+(defn fetch [url]
+  url)
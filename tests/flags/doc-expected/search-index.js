@@ -0,0 +1 @@
+var searchIndex = [{"name":"app.core","kind":"Namespace","url":"app.core.html","summary":"Entry point, using app.util/parse-int."},{"name":"app.core/*verbose*","kind":"Variable","url":"app.core.html#*verbose*","summary":"Whether to print more."},{"name":"app.core/-main","kind":"Function","url":"app.core.html#-main","summary":"Prints the sum of args (see app.util/parse-int)."},{"name":"app.util","kind":"Namespace","url":"app.util.html","summary":"Helpers shared by the app namespaces."},{"name":"app.util/\u003c=\u003e","kind":"Function","url":"app.util.html#\u003c=\u003e","summary":"Returns whether a \u003c b, a = b or a \u003e b as -1, 0 or 1."},{"name":"app.util/area","kind":"Multimethod","url":"app.util.html#area","summary":"Returns the area of a \u003cshape\u003e \u0026 nothing else."},{"name":"app.util/format-int","kind":"Function","url":"app.util.html#format-int","summary":"Formats n with pad leading zeros."},{"name":"app.util/helper","kind":"Function","url":"app.util.html#helper","summary":"Not part of the API."},{"name":"app.util/old-parse-int","kind":"Function","url":"app.util.html#old-parse-int","summary":""},{"name":"app.util/pad","kind":"Variable","url":"app.util.html#pad","summary":"The default padding."},{"name":"app.util/parse-int","kind":"Function","url":"app.util.html#parse-int","summary":"Parses s as an integer. See also format-int."},{"name":"app.util/with-pad","kind":"Macro","url":"app.util.html#with-pad","summary":"Evaluates body with pad bound to n."}];
@@ -0,0 +1,10 @@
+(ns app.core
+  "Entry point, using [[app.util/parse-int]]."
+  (:require [app.util :as u]))
+
+(def ^:dynamic *verbose* "Whether to print more." false)
+
+(defn -main
+  "Prints the sum of `args` (see [[app.util/parse-int]])."
+  [& args]
+  (println (reduce + (map u/parse-int args))))
@@ -0,0 +1,47 @@
+(ns app.util
+  "Helpers shared by the app namespaces.")
+
+(defn parse-int
+  "Parses s as an integer. See also [[format-int]]."
+  {:added "1.0"}
+  [s]
+  (Integer/parseInt s))
+
+(defn format-int
+  "Formats n with `pad` leading zeros."
+  ([n] (format-int n 0))
+  ([n pad] (format "%0{pad}d" n)))
+
+(def pad
+  "The default padding."
+  2)
+
+(defn ^:deprecated old-parse-int
+  {:deprecated "1.1" :superseded-by 'parse-int}
+  [s]
+  (parse-int s))
+
+(defn- helper
+  "Not part of the API."
+  [x]
+  x)
+
+(defmacro with-pad
+  "Evaluates body with `pad` bound to n."
+  [n & body]
+  `(let [pad# ~n] ~@body))
+
+(def ^:no-doc internal 1)
+
+(defmulti area
+  "Returns the area of a <shape> & nothing else."
+  (fn [shape] (:type shape)))
+
+(defmethod area :square
+  [{:keys [side]}]
+  (* side side))
+
+(defn <=>
+  "Returns whether a < b, a = b or a > b as -1, 0 or 1."
+  [a b]
+  (compare a b))
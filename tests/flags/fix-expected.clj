@@ -0,0 +1,16 @@
+(ns fix
+  (:require [clojure.string :as str]
+            [clojure.walk :as walk]))
+
+(defn f [x]
+  ;; Comments are kept.
+  (let [a (str/trim x)
+        _ 2]
+    (println a)
+    (walk/walk identity identity [x])))
+
+(defn g [{:keys [name] :as user} {}]
+  (def last-user user)
+  (println name))
+
+(ns fix.other)
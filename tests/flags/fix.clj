@@ -0,0 +1,18 @@
+(ns fix
+  (:require [clojure.walk :as walk]
+            [clojure.string :as str]
+            [clojure.template :as template]))
+
+(defn f [x]
+  ;; Comments are kept.
+  (let [a (str/trim x)
+        b 2]
+    (do (println a)
+        (walk/walk identity identity [x]))))
+
+(defn g [{:keys [name id] :as user} {:strs [k]}]
+  (def last-user user)
+  (println name))
+
+(ns fix.other
+  (:require [clojure.set :as set]))
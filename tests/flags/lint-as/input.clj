@@ -0,0 +1,12 @@
+(ns app.core
+  (:require [my.db :as db :refer [for-each-row]]
+            [my.web :refer [defroute]]))
+
+(defn users
+  []
+  (db/with-db [conn (db/connect) unused 1]
+    (for-each-row [row (db/query conn)]
+      (println (undefined-fn row)))))
+
+(defroute get-user [:get "/users/:id"] [id request]
+  (users id))
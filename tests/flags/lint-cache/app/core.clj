@@ -0,0 +1,8 @@
+(ns app.core
+  (:require [app.util :as u]))
+
+(defn -main
+  []
+  (u/greet "a" "b")
+  (inc (u/greet "x"))
+  (u/greet u/limit))
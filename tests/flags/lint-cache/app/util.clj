@@ -0,0 +1,7 @@
+(ns app.util)
+
+(defn ^String greet
+  [^String name]
+  (str "hi " name))
+
+(def limit 10)
@@ -0,0 +1,9 @@
+(ns app.a
+  (:require [app.b :as b :refer [foo]]
+            [app.c :refer [bar] :rename {foo c-foo}]))
+
+(defn run [x]
+  (let [foo 1] ; a local, not app.b/foo
+    (+ foo (b/foo x) (app.b/foo x) (foo x) (-> x foo) #'b/foo)))
+
+(b/with-foo (println "foo"))
@@ -0,0 +1,11 @@
+(ns app.b)
+
+(defn ^:private foo
+  "Doc mentioning foo."
+  [x]
+  (inc x))
+
+(defmacro with-foo [& body]
+  `(do (foo 1) ~@body))
+
+(def foobar (foo 2))
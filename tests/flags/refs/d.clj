@@ -0,0 +1,5 @@
+(ns app.d
+  (:require [app.b :refer [foo] :rename {foo b-foo}]))
+
+(defn run [x]
+  (b-foo x))
@@ -0,0 +1,5 @@
+(ns app.e)
+
+;; The linter warns about the method without args from a joker.core macro.
+(defprotocol Shape
+  (area []))
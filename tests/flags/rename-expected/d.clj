@@ -0,0 +1,5 @@
+(ns app.d
+  (:require [app.b :refer [baz] :rename {baz b-foo}]))
+
+(defn run [x]
+  (b-foo x))
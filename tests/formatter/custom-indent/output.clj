@@ -0,0 +1,60 @@
+(ns custom-indent
+  (:require [my.ns :as t]))
+
+(route :get "/users/:id"
+  [req]
+  (handle req))
+
+(route :get "/users/:id" [req]
+       (handle req))
+
+(transact conn
+  (query conn))
+
+(transact
+  conn
+  (query conn))
+
+(my.ns/thing Foo
+  (bar [this]
+    1))
+
+(t/thing Foo
+  (bar [this]
+    1))
+
+(defmacro ready
+  {:style/indent 1}
+  [x & body]
+  `(when ~x ~@body))
+
+(ready ready?
+  (println "ready"))
+
+(defmacro ^{:style/indent 0} in-tx
+  [& body]
+  `(do ~@body))
+
+(in-tx
+  (commit))
+
+(let [a   1
+      bcd 2
+      e   (+ a bcd)]
+  {:a        a
+   :bcd      bcd
+   :long-key e})
+
+(let [a 1 b 2]
+  {:a a :bb b})
+
+{:a 1
+ ;; comment
+ :bcd 2}
+
+(loop [i   0
+       acc []]
+  (recur (inc i) acc))
+
+(loop [i 0 acc []] (recur (inc i) acc)
+      1)
@@ -0,0 +1,14 @@
+;; Should PASS
+
+(cond
+  (= 1 2) 1
+  :else 2)
+
+;; Should FAIL
+
+(cond
+  (= 1 2) 1
+  (= 2 2) 2)
+(cond
+  (= 1 2) 1
+  :default 2)
@@ -0,0 +1,35 @@
+(ns old.lib
+  {:deprecated "2.0"})
+
+(defn f [] 1)
+
+(ns lib)
+
+(defn old-fn
+  {:deprecated "1.2"
+   :superseded-by 'lib/new-fn}
+  []
+  1)
+(defn new-fn [] 2)
+(defn ^:deprecated old-too [] 3)
+(defn ^:deprecated allowed [] 4)
+(defmacro ^:deprecated old-macro [] nil)
+
+;; Should PASS
+
+(old-fn)
+
+(ns user2
+  (:require [lib]
+            [old.lib]))
+
+(lib/new-fn)
+(lib/allowed)
+
+;; Should FAIL
+
+(lib/old-fn)
+(lib/old-too)
+(lib/old-macro)
+#'lib/old-fn
+(old.lib/f)
@@ -0,0 +1,17 @@
+;; Should PASS
+
+(defn f1
+  "Docstring."
+  [x]
+  x)
+(defn f2 [] "value")
+
+;; Should FAIL
+
+(defn f3 [x] "Docstring." x)
+(defn- f4
+  ([] 1)
+  ([x] "Docstring." x))
+(defmacro m1 [x] "Docstring." x)
+
+(f4 1)
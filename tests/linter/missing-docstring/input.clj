@@ -0,0 +1,19 @@
+(ns test)
+
+;; Should PASS
+
+(defn f1
+  "Docstring."
+  [x]
+  x)
+(defn- f2 [x] x)
+(def ^:private v1 1)
+(def v2 "Docstring." 2)
+(declare f5)
+
+;; Should FAIL
+
+(defn f3 [x] x)
+(defmacro m1 [x] x)
+(def v3 3)
+(defn f5 [] (f1 (f2 (m1 v1))))
@@ -0,0 +1,7 @@
+;; Should PASS
+
+(ns test
+  (:require [test.ns1]
+            [test.ns1]))
+
+(test.ns1/f)
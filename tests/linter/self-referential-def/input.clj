@@ -0,0 +1,11 @@
+;; Should PASS
+
+(defn f [x] (if (pos? x) (f (dec x)) x))
+(def g (fn [] g))
+(def h (delay h))
+(def k (fn k [x] (if (pos? x) (k (dec x)) x)))
+
+;; Should FAIL
+
+(def v (inc v))
+(def m {:self m})
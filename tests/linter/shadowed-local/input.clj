@@ -0,0 +1,11 @@
+;; Should PASS
+
+(let [a 1 b 2] (+ a b))
+(as-> 1 $ (inc $) (* $ 2))
+(let [{:keys [a]} {:a 1}] a)
+
+;; Should FAIL
+
+(let [a 1 a (inc a)] a)
+(fn [x] (let [x (inc x)] x))
+(let [y 1] ((fn [y] y) y))
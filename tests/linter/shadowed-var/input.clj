@@ -0,0 +1,13 @@
+(ns test)
+
+(defn parse [s] s)
+
+;; Should PASS
+
+(let [text "a"] text)
+(fn [_count] _count)
+
+;; Should FAIL
+
+(let [parse "a"] parse)
+(fn [count] count)
@@ -0,0 +1,22 @@
+(defn f
+  [a b]
+  (let [x (str a)
+        y x]
+    (inc y)
+    (inc (if b "a" "b"))
+    (inc (if b x 1))
+    (inc (-> a str))
+    (inc (do (println a) (str a)))))
+
+(def greeting (str "hello"))
+
+(defn g
+  []
+  (inc greeting)
+  (let [v (if greeting [1] [2])]
+    (inc v)))
+
+(defn h
+  [b]
+  (let [w (if b "a" (str b))]
+    (inc w)))
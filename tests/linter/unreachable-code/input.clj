@@ -0,0 +1,17 @@
+;; Should PASS
+
+(defn f1 [x] (if x (throw (ex-info "x" {})) 1))
+(loop [x 1] (when (< x 10) (recur (inc x))))
+(defn f3 [c] (while c (throw (ex-info "c" {}))))
+
+;; Should FAIL
+
+(defn f2 []
+  (throw (ex-info "x" {}))
+  (println "done")
+  (println "really done"))
+(loop [x 1]
+  (if (< x 10)
+    (do (recur (inc x))
+        (println x))
+    x))
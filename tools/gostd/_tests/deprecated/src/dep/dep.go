@@ -0,0 +1,37 @@
+// Package dep has deprecated APIs.
+package dep
+
+// Old is an old type.
+//
+// Deprecated: Use New instead.
+type Old struct{ N int }
+
+// MakeOld makes an Old.
+func MakeOld(n int) *Old { return &Old{N: n} }
+
+// Get returns N.
+func (o *Old) Get() int { return o.N }
+
+// Thing is a thing.
+type Thing struct{ N int }
+
+// MakeThing makes a Thing.
+func MakeThing(n int) Thing { return Thing{N: n} }
+
+// Size returns the size.
+//
+// Deprecated: Use Len.
+func (t Thing) Size() int { return t.N }
+
+// Len returns the length.
+func (t Thing) Len() int { return t.N }
+
+// Twice doubles n.
+//
+// Deprecated: Use n*2.
+func Twice(n int) int { return n * 2 }
+
+// Limit is a limit.
+//
+// Deprecated: There is no limit.
+const Limit = 10
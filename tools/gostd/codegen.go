@@ -87,11 +87,12 @@ type funcCode struct {
 	goParamListDoc          string
 	clojureGoParams         string
 	goCode                  string
-	clojureReturnType       string
+	clojureReturnType       string // empty or a type tag, e.g. ^"Int"
 	clojureReturnTypeForDoc string
 	goReturnType            string
 	goReturnTypeForDoc      string
 	conversion              string // empty if no conversion, else conversion expression with %s as expr to be converted
+	isNativeCodeNeeded      bool   // whether a Go function wraps the call
 }
 
 func genGoCall(goFname, goParams string) string {
@@ -114,9 +115,13 @@ func genFuncCode(fn *FuncInfo, t *types.Signature) (fc funcCode) {
 	goResultAssign, fc.clojureReturnType, fc.clojureReturnTypeForDoc, fc.goReturnTypeForDoc, goPostCode, fc.conversion = genGoPost("\t", t)
 
 	fc.clojureReturnType, fc.goReturnType = genutils.ClojureReturnTypeForGenerateCustom(fc.clojureReturnType)
-	isNativeCodeNeeded := fc.clojureReturnType == "" // No Go code needs to be generated when a return type is explicitly specified.
+	fc.isNativeCodeNeeded = fc.clojureReturnType == "" // No Go code needs to be generated when a return type is explicitly specified.
+	if releasesGIL(fn) {
+		// Only native code can release the GIL around the call.
+		fc.isNativeCodeNeeded, fc.goReturnType = true, "Object"
+	}
 
-	fc.clojureParamList, fc.clojureParamListDoc, fc.clojureGoParams, fc.goAutoGenParamList, fc.goNativeParamList, fc.goParamListDoc, goParams = genGoPreFunc(fn, isNativeCodeNeeded)
+	fc.clojureParamList, fc.clojureParamListDoc, fc.clojureGoParams, fc.goAutoGenParamList, fc.goNativeParamList, fc.goParamListDoc, goParams = genGoPreFunc(fn, fc.isNativeCodeNeeded)
 
 	goCall := genGoCall(fn.BaseName, goParams)
 
@@ -124,8 +129,13 @@ func genFuncCode(fn *FuncInfo, t *types.Signature) (fc funcCode) {
 		goPostCode = "\treturn NIL\n"
 	}
 
-	fc.goCode = "\t" + goResultAssign + goCall + // [results := ]fn-to-call([args...])
-		goPostCode // Optional block of post-code
+	goCode := "\t" + goResultAssign + goCall // [results := ]fn-to-call([args...])
+	if releasesGIL(fn) {
+		goCode = withGILReleased(goCode, goPostCode)
+	} else {
+		goCode += goPostCode // Optional block of post-code
+	}
+	fc.goCode = goCode
 	return
 }
 
@@ -169,7 +179,13 @@ func genReceiverCode(fn *FuncInfo, goFname string) string {
 	Templates.ExecuteTemplate(buf, "go-receiver-arity.tmpl", ai)
 	arity := buf.String()
 
-	return arity + preCode + "\t" + resultAssign + call + "\n" + postCode
+	callCode := "\t" + resultAssign + call + "\n"
+	if releasesGIL(fn) {
+		callCode = withGILReleased(callCode, postCode)
+	} else {
+		callCode += postCode
+	}
+	return arity + preCode + callCode
 }
 
 func GenReceiver(fn *FuncInfo) {
@@ -258,6 +274,26 @@ func GenReceiver(fn *FuncInfo) {
 	}
 }
 
+// Return the :deprecated metadata entry for a standalone function, if
+// its doc comment says it's deprecated or it's a constructor (per
+// go/doc, its first result is, or points to, a type declared in the
+// same package) of a deprecated type.
+func standaloneDeprecatedMeta(fn *FuncInfo, indent string) string {
+	doc := genutils.CommentGroupAsString(fn.Fd.Doc)
+	if res := fn.Signature.Results(); !genutils.IsDeprecated(doc) && res.Len() > 0 {
+		ty := res.At(0).Type()
+		if ptr, ok := ty.(*types.Pointer); ok {
+			ty = ptr.Elem()
+		}
+		if _, ok := ty.(*types.Named); ok {
+			if ti := TypeInfoForType(ty); ti != nil && ti.GoFile() != nil && ti.GoFile().Package == fn.SourceFile.Package {
+				doc = ti.Doc()
+			}
+		}
+	}
+	return genutils.DeprecatedMeta(doc, indent)
+}
+
 func GenStandalone(fn *FuncInfo) {
 	genutils.GenSymReset()
 	d := fn.Fd
@@ -267,14 +303,16 @@ func GenStandalone(fn *FuncInfo) {
 
 	goFname := genutils.FuncNameAsGoPrivate(d.Name.Name)
 	fc := genFuncCode(fn, fn.Signature)
-	isNativeCodeNeeded := fc.clojureReturnType == "" // No Go code needs to be generated when a return type is explicitly specified.
+	isNativeCodeNeeded := fc.isNativeCodeNeeded
 
+	if fc.clojureReturnType != "" {
+		fc.clojureReturnType += " "
+	}
 	var cl2gol string
 	if isNativeCodeNeeded {
 		cl2gol = goFname
 		fc.conversion = ""
 	} else {
-		fc.clojureReturnType += " "
 		cl2gol = pkgBaseName + "." + fn.BaseName
 		if _, found := NamespacesInfo[ns]; !found {
 			panic(fmt.Sprintf("Cannot find namespace %s", ns))
@@ -290,8 +328,9 @@ func GenStandalone(fn *FuncInfo) {
 		"Name":       d.Name.Name,
 		"DocString": genutils.CommentGroupInQuotes(d.Doc, fc.clojureParamListDoc, fc.clojureReturnTypeForDoc,
 			fc.goParamListDoc, fc.goReturnTypeForDoc) + "\n",
-		"GoCode":    cl2golCall,
-		"ParamList": fc.clojureParamList,
+		"Deprecated": standaloneDeprecatedMeta(fn, "   "),
+		"GoCode":     cl2golCall,
+		"ParamList":  fc.clojureParamList,
 	}
 
 	buf := new(bytes.Buffer)
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Runs gostd on _tests/deprecated/src/dep, whose APIs are documented
+// with "Deprecated: " paragraphs, and checks which of the generated
+// vars and receivers are marked :deprecated.
+func TestDeprecated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs gostd")
+	}
+	output := runGostd(t)
+
+	joke := readGenerated(t, filepath.Join(output, "std", "gostd", "go", "std", "dep.joke"))
+	for name, deprecated := range map[string]bool{
+		"Limit":        true,
+		"Twice":        true,
+		"MakeOld":      true, // Constructor of a deprecated type.
+		"MakeThing":    false,
+		"Old":          true,
+		"*Old":         true,
+		"arrayOfOld":   true,
+		"Thing":        false,
+		"*Thing":       false,
+		"arrayOfThing": false,
+	} {
+		def := jokerDef(joke, name)
+		if def == "" {
+			t.Errorf("%s is not defined:\n%s", name, joke)
+		} else if strings.Contains(def, ":deprecated true") != deprecated {
+			t.Errorf("%s: expected deprecated=%v:\n%s", name, deprecated, def)
+		}
+	}
+
+	native := readGenerated(t, filepath.Join(output, "std", "gostd", "go", "std", "dep", "dep_native.go"))
+	for _, receiver := range []string{
+		`"Get": MakeDeprecatedGoReceiver(`, // Method of a deprecated type.
+		`"Len": MakeGoReceiver(`,
+		`"Size": MakeDeprecatedGoReceiver(`,
+	} {
+		if !strings.Contains(native, receiver) {
+			t.Errorf("expected %s in:\n%s", receiver, native)
+		}
+	}
+}
+
+// runGostd builds gostd and runs it with args on _tests/deprecated,
+// returning the output directory.
+func runGostd(t *testing.T, args ...string) string {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "gostd")
+	if out, err := exec.Command("go", "build", "-o", exe, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %s\n%s", err, out)
+	}
+	output := filepath.Join(dir, "out")
+	if err := os.MkdirAll(filepath.Join(output, "core", "data"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	goRoot, err := filepath.Abs(filepath.Join("_tests", "deprecated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args = append([]string{"--go-root", goRoot, "--joker", filepath.Join("..", ".."),
+		"--output", output, "--replace", "--no-timestamp"}, args...)
+	if out, err := exec.Command(exe, args...).CombinedOutput(); err != nil {
+		t.Fatalf("gostd: %s\n%s", err, out)
+	}
+	return output
+}
+
+func readGenerated(t *testing.T, filename string) string {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Returns the def or defn form of name in joke, or "".
+func jokerDef(joke, name string) string {
+	for _, form := range strings.Split(joke, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(form), "\n")
+		last := strings.TrimSpace(lines[len(lines)-1])
+		first := strings.Fields(lines[0])
+		if last == name+")" || (first[0] == "(defn" && first[len(first)-1] == name) {
+			return form
+		}
+	}
+	return ""
+}
@@ -110,6 +110,27 @@ func CommentGroupInQuotes(doc *CommentGroup, jokIn, jokOut, goIn, goOut string)
 	return strings.Trim(strconv.Quote(d), " \t\n")
 }
 
+// Whether the doc comment text has a paragraph starting with
+// "Deprecated: " (per Go convention).
+func IsDeprecated(doc string) bool {
+	for _, para := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(para, "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the :deprecated metadata entry, preceded by indent and
+// followed by a newline, if the doc comment text says it's deprecated
+// (see IsDeprecated), else "".
+func DeprecatedMeta(doc, indent string) string {
+	if IsDeprecated(doc) {
+		return indent + ":deprecated true\n"
+	}
+	return ""
+}
+
 var outs map[string]struct{}
 
 func StartSortedStdout() {
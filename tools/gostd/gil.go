@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+)
+
+// Generated wrappers release the GIL (RT.GIL) while calling Go APIs
+// that may block (on network or file I/O, synchronization, timers,
+// etc.), so that other goroutines (such as go blocks) can run Joker
+// code in the meantime.
+//
+// gilRelease maps API specifications to whether calls to the
+// matching APIs release the GIL. A specification is one of (in order
+// of decreasing precedence):
+//
+//	<pkg>.<type>.<method>  e.g. "net/http.Client.Do"
+//	<pkg>.<type>           all methods of the type, e.g. "net.IP"
+//	<pkg>.<func>           a standalone function, e.g. "time.Sleep"
+//	<pkg>                  all functions and methods in the package
+//
+// APIs not matching any specification do not release the GIL.
+// Entries can be added or overridden via the --release-gil and
+// --hold-gil options.
+var gilRelease = map[string]bool{
+	"bufio":         true,
+	"crypto/tls":    true,
+	"database/sql":  true,
+	"io":            true,
+	"io/fs":         true,
+	"io/ioutil":     true,
+	"log/syslog":    true,
+	"net":           true,
+	"net/http":      true,
+	"net/rpc":       true,
+	"net/smtp":      true,
+	"net/textproto": true,
+	"os":            true,
+	"os/exec":       true,
+	"os/signal":     true,
+	"sync":          true,
+	"syscall":       true,
+
+	"path/filepath.Glob":    true,
+	"path/filepath.Walk":    true,
+	"path/filepath.WalkDir": true,
+	"time.Sleep":            true,
+
+	// Value types and pure functions in otherwise blocking packages.
+	"net.CIDRMask":                false,
+	"net.HardwareAddr":            false,
+	"net.IP":                      false,
+	"net.IPMask":                  false,
+	"net.IPNet":                   false,
+	"net.IPv4":                    false,
+	"net.IPv4Mask":                false,
+	"net.JoinHostPort":            false,
+	"net.ParseCIDR":               false,
+	"net.ParseIP":                 false,
+	"net.ParseMAC":                false,
+	"net.SplitHostPort":           false,
+	"net/http.CanonicalHeaderKey": false,
+	"net/http.Header":             false,
+	"net/http.StatusText":         false,
+	"os.Getenv":                   false,
+	"os.Getpid":                   false,
+	"os.IsExist":                  false,
+	"os.IsNotExist":               false,
+	"os.IsPermission":             false,
+	"os.LookupEnv":                false,
+}
+
+// setGILRelease records the API specifications from the --release-gil
+// or --hold-gil option.
+func setGILRelease(specs []string, release bool) {
+	for _, s := range specs {
+		gilRelease[s] = release
+	}
+}
+
+// releasesGIL returns whether the wrapper for fn should release the GIL
+// around the call to fn.
+func releasesGIL(fn *FuncInfo) bool {
+	pkg := fn.SourceFile.Package.Dir.String()
+	typ := ""
+	if fn.ToM != nil {
+		pkg = fn.ToM.GoPackage()
+		typ = fn.ToM.GoBaseName()
+	} else if fn.ReceiverType != "" {
+		typ = strings.TrimPrefix(strings.TrimLeft(fn.ReceiverType, "*[]"), "{{myGoImport}}.")
+	}
+	specs := []string{pkg}
+	if typ != "" {
+		specs = append(specs, pkg+"."+typ, pkg+"."+typ+"."+fn.BaseName)
+	} else {
+		specs = append(specs, pkg+"."+fn.BaseName)
+	}
+	for i := len(specs) - 1; i >= 0; i-- {
+		if release, found := gilRelease[specs[i]]; found {
+			return release
+		}
+	}
+	return false
+}
+
+// withGILReleased wraps call (a line of Go code, indented with a tab,
+// that calls a Go API and assigns its results, if any) and post (lines
+// of Go code that convert the results and return an Object) so that
+// only call runs with the GIL released (see ReleaseGIL in
+// core/interpreter.go): post may allocate Joker objects and raise
+// errors, so the GIL is reacquired before it runs. The GIL is also
+// reacquired if call panics.
+func withGILReleased(call, post string) string {
+	return "\treturn func() Object {\n" +
+		"\t\t_in := ReleaseGIL()\n" +
+		"\t\t_held := false\n" +
+		"\t\tdefer func() {\n" +
+		"\t\t\tif !_held {\n" +
+		"\t\t\t\t_in.Enter()\n" +
+		"\t\t\t}\n" +
+		"\t\t}()\n" +
+		nonEmptyLineRegexp.ReplaceAllString(call, "\t$1") +
+		"\t\t_in.Enter()\n" +
+		"\t\t_held = true\n" +
+		nonEmptyLineRegexp.ReplaceAllString(post, "\t$1") +
+		"\t}()\n"
+}
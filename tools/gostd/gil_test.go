@@ -0,0 +1,63 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithGILReleased(t *testing.T) {
+	call := "\tn, err := net.Dial(_v_network, _v_address)\n"
+	post := "\t_res := EmptyVector()\n" +
+		"\t_res = _res.Conjoin(MakeGoObjectIfNeeded(n))\n" +
+		"\t_res = _res.Conjoin(MakeError(err))\n" +
+		"\treturn _res\n"
+	src := "package p\n\nfunc dial(_v_network, _v_address string) Object {\n" + withGILReleased(call, post) + "}\n"
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body.List
+	ret, ok := body[len(body)-1].(*ast.ReturnStmt)
+	if len(body) != 1 || !ok {
+		t.Fatalf("expected a single return statement:\n%s", src)
+	}
+	lit := ret.Results[0].(*ast.CallExpr).Fun.(*ast.FuncLit)
+	if _, ok := lit.Body.List[2].(*ast.DeferStmt); !ok {
+		t.Errorf("GIL is not reacquired in a deferred call:\n%s", src)
+	}
+	if !strings.Contains(src, "\t\tn, err := net.Dial(_v_network, _v_address)\n\t\t_in.Enter()\n") {
+		t.Errorf("GIL is not reacquired right after the call:\n%s", src)
+	}
+	if !strings.Contains(src, "\t\t_held = true\n\t\t_res := EmptyVector()\n") {
+		t.Errorf("results are not converted with the GIL held:\n%s", src)
+	}
+}
+
+// Runs gostd on _tests/deprecated/src/dep with some of its APIs
+// releasing the GIL, and checks that their wrappers keep their return
+// type tags and convert the results with the GIL held.
+func TestReleaseGIL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs gostd")
+	}
+	output := runGostd(t, "--release-gil", "dep.Twice", "dep.Thing.Len")
+
+	joke := readGenerated(t, filepath.Join(output, "std", "gostd", "go", "std", "dep.joke"))
+	if def := jokerDef(joke, "Twice"); !strings.HasPrefix(def, `(defn ^"Int" Twice`) || !strings.Contains(def, `:go "_f_Twice(`) {
+		t.Errorf("Twice is not tagged or not wrapped:\n%s", def)
+	}
+
+	native := readGenerated(t, filepath.Join(output, "std", "gostd", "go", "std", "dep", "dep_native.go"))
+	for _, call := range []string{
+		"_res := dep.Twice(_v_n)\n\t\t_in.Enter()\n\t\t_held = true\n\t\treturn MakeInt(_res)\n",
+		"_res := o.O.(dep.Thing).Len()\n\t\t_in.Enter()\n\t\t_held = true\n\t\treturn MakeInt(_res)\n",
+	} {
+		if !strings.Contains(native, call) {
+			t.Errorf("expected %q in:\n%s", call, native)
+		}
+	}
+}
@@ -60,6 +60,8 @@ Options:
   --go-path <gopath-dir>      # Overrides $GOPATH as "root" of <package-spec> specifications
   --go-root <goroot-dir>      # Location of Golang's src/ subdirectory (defaults to build.Default.GOROOT)
   --others <package-spec>...  # Location of other package directories, or a file with one <package-spec> per line
+  --release-gil <api-spec>... # Release the GIL around calls to these APIs (see gil.go for <api-spec> syntax)
+  --hold-gil <api-spec>...    # Hold the GIL around calls to these APIs, overriding the default list of blocking APIs
   --output <new-code-dir>     # Modify pertinent source files here to reflect packages being created (default: ".")
   --joker <joker-dir>         # Where to find the core/ directory with the APIs that generated Joker code may thus call (default: <new-code-dir>)
   --overwrite                 # Overwrite any existing <new-code-dir> files, leaving existing files intact
@@ -198,6 +200,17 @@ func main() {
 					i += 1 // shift
 					others = append(others, paths.NewPathAsNative(os.Args[i]))
 				}
+			case "--release-gil", "--hold-gil":
+				if i >= length-1 || !notOption(os.Args[i+1]) {
+					fmt.Fprintf(os.Stderr, "missing api-spec(s) after %s option\n", a)
+					os.Exit(1)
+				}
+				var specs []string
+				for i < length-1 && notOption(os.Args[i+1]) {
+					i += 1 // shift
+					specs = append(specs, os.Args[i])
+				}
+				setGILRelease(specs, a == "--release-gil")
 			case "--go-path":
 				if i < length-1 && notOption(os.Args[i+1]) {
 					i += 1 // shift
@@ -229,6 +229,7 @@ func outputClojureCode(ns string, v CodeInfo, clojureLibDir string, generateEmpt
 			info := map[string]string{
 				"Doc":         strconv.Quote(typeDoc),
 				"Specificity": specificity,
+				"Deprecated":  genutils.DeprecatedMeta(typeDoc, "    "),
 				"GoName":      tmn,
 				"ClojureName": fmt.Sprintf(ti.ClojurePattern(), ti.ClojureBaseName()),
 			}
@@ -348,12 +349,16 @@ func outputGoCode(ns string, v CodeInfo, clojureLibDir string, generateEmpty boo
 			mem := ""
 			SortedFnCodeInfo(v.InitVars[ti], // Will always be populated
 				func(c string, r *FnCodeInfo) {
-					doc := r.FnDoc
+					doc := genutils.CommentGroupAsString(r.FnDoc)
 					g := r.FnCode
+					maker := "MakeGoReceiver"
+					if genutils.IsDeprecated(doc) || genutils.IsDeprecated(ti.Doc()) {
+						maker = "MakeDeprecatedGoReceiver"
+					}
 					mem += fmt.Sprintf(`
-			"%s": MakeGoReceiver("%s", %s, %s, %s, NewVectorFrom(%s)),
+			"%s": %s("%s", %s, %s, %s, NewVectorFrom(%s)),
 `[1:],
-						c, c, g, strconv.Quote(genutils.CommentGroupAsString(doc)), strconv.Quote("1.0"), paramsAsSymbolVec(r.Params))
+						c, maker, c, g, strconv.Quote(doc), strconv.Quote("1.0"), paramsAsSymbolVec(r.Params))
 				})
 
 			info := map[string]string{
@@ -588,7 +588,7 @@ func isBasicLiteral(e Expr) *BasicLit {
 	}
 }
 
-func processConstantSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, val Expr, docString string) bool {
+func processConstantSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, val Expr, docString, deprecated string) bool {
 	defer func() {
 		if x := recover(); x != nil {
 			panic(fmt.Sprintf("(Panic at %s processing %+v: %+v)\n", godb.WhereAt(name.Pos()), name, x))
@@ -612,6 +612,7 @@ func processConstantSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, val Ex
 	// Note: :tag value is a string to avoid conflict with like-named member of namespace
 	constantDefInfo := map[string]string{
 		"DocString":       docString,
+		"Deprecated":      deprecated,
 		"ValueTypeString": valTypeString,
 		"GoCode":          goCode,
 		"ClojureName":     clName,
@@ -628,7 +629,7 @@ func processConstantSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, val Ex
 	return true
 }
 
-func processVariableSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, docString string) bool {
+func processVariableSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, docString, deprecated string) bool {
 	clName := name.Name
 	localName := gf.Package.BaseName + "." + name.Name
 	fullName := pkgDirUnix + "." + name.Name
@@ -641,6 +642,7 @@ func processVariableSpec(gf *godb.GoFile, pkgDirUnix string, name *Ident, docStr
 	// Note: :tag value is a string to avoid conflict with like-named member of namespace
 	variableDefInfo := map[string]string{
 		"DocString":   docString,
+		"Deprecated":  deprecated,
 		"LocalName":   localName,
 		"ClojureName": clName,
 	}
@@ -711,10 +713,11 @@ func processValueSpecs(gf *godb.GoFile, pkgDirUnix string, tss []Spec, parentDoc
 				doc = parentDoc // Use 'var'/'const' statement block comments as last resort
 			}
 			docString := genutils.CommentGroupInQuotes(doc, "", "", "", "")
+			deprecated := genutils.DeprecatedMeta(genutils.CommentGroupAsString(doc), "    ")
 			if constant {
-				processConstantSpec(gf, pkgDirUnix, valName, val, docString)
+				processConstantSpec(gf, pkgDirUnix, valName, val, docString, deprecated)
 			} else {
-				processVariableSpec(gf, pkgDirUnix, valName, docString)
+				processVariableSpec(gf, pkgDirUnix, valName, docString, deprecated)
 			}
 		}
 	}